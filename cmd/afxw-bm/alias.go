@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/finder"
+)
+
+// runGo はエイリアスに一致するブックマークへファインダーを開かずに移動します。
+// 候補が1つに決まらない場合は、エイリアスを検索語に入れた状態でファインダーを開きます。
+func runGo(ctx context.Context, a afx.AFX, f finder.Finder, sources []bookmark.Source, alias string, opposite bool) error {
+	bookmarks, err := loadBookmarks(sources)
+	if err != nil {
+		return err
+	}
+
+	idx, err := bookmark.Resolve(bookmarks, alias)
+	switch {
+	case errors.Is(err, bookmark.ErrAmbiguous):
+		idx, err = finder.FindWithOptions(f, bookmark.Format(bookmarks), finder.Options{
+			Header: fmt.Sprintf("%q に一致するブックマークが複数あります", alias),
+			Query:  alias,
+		})
+		if err != nil {
+			// ESCやCtrl+Cでキャンセルされた場合は正常終了
+			if errors.Is(err, fuzzyfinder.ErrAbort) {
+				return nil
+			}
+			return err
+		}
+	case errors.Is(err, bookmark.ErrNotFound):
+		if suggestions := bookmark.Suggest(bookmarks, alias); len(suggestions) > 0 {
+			return fmt.Errorf("%w（候補: %s）", err, strings.Join(suggestions, ", "))
+		}
+		return err
+	case err != nil:
+		return err
+	}
+
+	return jumpTo(ctx, a, bookmarks[idx], opposite)
+}
+
+// runAlias は args[0] に一致するブックマークのエイリアスを args[1] に設定します。
+// args[1] を省略した場合はエイリアスを削除します。
+func runAlias(w io.Writer, sources []bookmark.Source, args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return errors.New("名前またはパスとエイリアスを指定してください")
+	}
+	alias := ""
+	if len(args) == 2 {
+		alias = args[1]
+	}
+
+	b, bmPath, err := findWritable(sources, args[0])
+	if err != nil {
+		return fmt.Errorf("エイリアスの設定に失敗しました: %w", err)
+	}
+	if err := bookmark.SetAlias(bmPath, b.Path, alias); err != nil {
+		return fmt.Errorf("エイリアスの設定に失敗しました: %w", err)
+	}
+	if alias == "" {
+		fmt.Fprintf(w, "エイリアスを削除しました: %s\n", args[0])
+	} else {
+		fmt.Fprintf(w, "エイリアスを設定しました: %s -> %s\n", alias, args[0])
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+)
+
+func writeAliasedBookmarks(t *testing.T) string {
+	t.Helper()
+	bmPath := filepath.Join(t.TempDir(), bookmark.FileName)
+	bookmarks := []bookmark.Bookmark{
+		{Name: "work", Path: `C:\Work`, Alias: "w"},
+		{Name: "docs", Path: `C:\Users\Test\Documents`},
+		{Name: "downloads", Path: `C:\Users\Test\Downloads`},
+	}
+	if err := bookmark.Save(bmPath, bookmarks); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+	return bmPath
+}
+
+func TestRunGo(t *testing.T) {
+	tests := []struct {
+		name          string
+		alias         string
+		finder        *afxtest.MockFinder
+		expectedPath  string
+		expectedQuery string
+		expectedErr   error
+		errContains   string
+	}{
+		{
+			name:         "エイリアスで移動",
+			alias:        "w",
+			finder:       &afxtest.MockFinder{},
+			expectedPath: `C:\Work`,
+		},
+		{
+			name:         "名前の前方一致で移動",
+			alias:        "doc",
+			finder:       &afxtest.MockFinder{},
+			expectedPath: `C:\Users\Test\Documents`,
+		},
+		{
+			name:          "候補が複数ある場合はファインダーで選択",
+			alias:         "do",
+			finder:        &afxtest.MockFinder{Idx: 2},
+			expectedPath:  `C:\Users\Test\Downloads`,
+			expectedQuery: "do",
+		},
+		{
+			name:          "ファインダーでキャンセル",
+			alias:         "do",
+			finder:        &afxtest.MockFinder{Err: fuzzyfinder.ErrAbort},
+			expectedQuery: "do",
+		},
+		{
+			name:        "見つからない場合は候補を表示",
+			alias:       "wrok",
+			finder:      &afxtest.MockFinder{},
+			expectedErr: bookmark.ErrNotFound,
+			errContains: "候補: work",
+		},
+		{
+			name:        "候補もない場合",
+			alias:       "zzzzzz",
+			finder:      &afxtest.MockFinder{},
+			expectedErr: bookmark.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bmPath := writeAliasedBookmarks(t)
+			afxMock := &afxtest.MockAFX{}
+
+			err := runGo(t.Context(), afxMock, tt.finder, personal(bmPath), tt.alias, false)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("エラー 期待: %v, 取得: %v", tt.expectedErr, err)
+			}
+			if tt.errContains != "" && !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("エラーメッセージに %q が含まれていません: %v", tt.errContains, err)
+			}
+			if afxMock.ExcdPath != tt.expectedPath {
+				t.Errorf("期待: %q, 取得: %q", tt.expectedPath, afxMock.ExcdPath)
+			}
+			if tt.finder.Opts.Query != tt.expectedQuery {
+				t.Errorf("検索語 期待: %q, 取得: %q", tt.expectedQuery, tt.finder.Opts.Query)
+			}
+			// 一意に決まる場合はファインダーを開かない
+			if tt.expectedQuery == "" && len(tt.finder.Calls) != 0 {
+				t.Errorf("ファインダーが開かれるべきではありません: %d回", len(tt.finder.Calls))
+			}
+		})
+	}
+}
+
+func TestRunGo_Opposite(t *testing.T) {
+	bmPath := writeAliasedBookmarks(t)
+	afxMock := &afxtest.MockAFX{}
+
+	if err := runGo(t.Context(), afxMock, &afxtest.MockFinder{}, personal(bmPath), "w", true); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if afxMock.ExcdOppositePath != `C:\Work` || afxMock.ExcdPath != "" {
+		t.Errorf("反対窓のみ移動するべきです: opposite=%q active=%q", afxMock.ExcdOppositePath, afxMock.ExcdPath)
+	}
+}
+
+func TestRunAlias(t *testing.T) {
+	bmPath := writeAliasedBookmarks(t)
+
+	if err := runAlias(&bytes.Buffer{}, personal(bmPath), nil); err == nil {
+		t.Error("引数がない場合はエラーが期待されましたが、nilが返りました")
+	}
+	if err := runAlias(&bytes.Buffer{}, personal(bmPath), []string{"docs", "d"}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if err := runAlias(&bytes.Buffer{}, personal(bmPath), []string{"work"}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	bookmarks, err := bookmark.Load(bmPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if bookmarks[0].Alias != "" || bookmarks[1].Alias != "d" {
+		t.Errorf("期待: %q, %q, 取得: %q, %q", "", "d", bookmarks[0].Alias, bookmarks[1].Alias)
+	}
+}
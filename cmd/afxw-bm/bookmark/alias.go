@@ -0,0 +1,134 @@
+package bookmark
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrAliasInUse は指定されたエイリアスが他のブックマークで使われていることを表します。
+var ErrAliasInUse = errors.New("エイリアスは他のブックマークで使われています")
+
+// maxSuggestions は Suggest が返す候補の最大数です。
+const maxSuggestions = 3
+
+// Resolve はエイリアスに一致するブックマークの位置を返します。
+// エイリアスの完全一致、名前の完全一致、エイリアスまたは名前の前方一致の順に探し、
+// 最初に見つかった段階で候補が1つに決まらなければ ErrAmbiguous を返します。
+// いずれにも一致しない場合は ErrNotFound を返します。
+// 大文字小文字は区別しません。
+func Resolve(bookmarks []Bookmark, alias string) (int, error) {
+	matchers := []func(b Bookmark) bool{
+		func(b Bookmark) bool { return strings.EqualFold(b.Alias, alias) },
+		func(b Bookmark) bool { return strings.EqualFold(b.Name, alias) },
+		func(b Bookmark) bool { return hasPrefixFold(b.Alias, alias) || hasPrefixFold(b.Name, alias) },
+	}
+	for _, match := range matchers {
+		found := -1
+		for i, b := range bookmarks {
+			if !match(b) {
+				continue
+			}
+			if found >= 0 {
+				return -1, fmt.Errorf("%w: %s", ErrAmbiguous, alias)
+			}
+			found = i
+		}
+		if found >= 0 {
+			return found, nil
+		}
+	}
+	return -1, fmt.Errorf("%w: %s", ErrNotFound, alias)
+}
+
+// SetAlias は名前またはパスが key に一致するブックマークのエイリアスを alias に設定します。
+// alias が空の場合はエイリアスを削除します。
+// 他のブックマークが同じエイリアスを使っている場合は ErrAliasInUse を返します。
+func SetAlias(path string, key string, alias string) error {
+	alias = strings.TrimSpace(alias)
+	return Update(path, func(bookmarks []Bookmark) ([]Bookmark, error) {
+		i, err := Lookup(bookmarks, key)
+		if err != nil {
+			return nil, err
+		}
+		if alias != "" {
+			for j, b := range bookmarks {
+				if j != i && strings.EqualFold(b.Alias, alias) {
+					return nil, fmt.Errorf("%w: %s（%s）", ErrAliasInUse, alias, b.Path)
+				}
+			}
+		}
+		bookmarks[i].Alias = alias
+		return bookmarks, nil
+	})
+}
+
+// Suggest は alias と編集距離が近いエイリアスや名前を近い順に最大 maxSuggestions 件返します。
+// 入力の長さに対して離れすぎているものは返しません。
+func Suggest(bookmarks []Bookmark, alias string) []string {
+	type candidate struct {
+		word string
+		dist int
+	}
+
+	limit := max(1, len([]rune(alias))/3)
+	var candidates []candidate
+	seen := make(map[string]struct{})
+	for _, b := range bookmarks {
+		for _, word := range []string{b.Alias, b.Name} {
+			if word == "" {
+				continue
+			}
+			key := strings.ToLower(word)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			if d := editDistance(strings.ToLower(alias), key); d <= limit {
+				candidates = append(candidates, candidate{word, d})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].dist < candidates[j].dist
+	})
+	var words []string
+	for _, c := range candidates[:min(len(candidates), maxSuggestions)] {
+		words = append(words, c.word)
+	}
+	return words
+}
+
+// editDistance は a と b の編集距離を文字単位で返します。
+// 挿入・削除・置換に加えて、打ち間違えやすい隣り合う2文字の入れ替えも1回の操作として数えます。
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// hasPrefixFold は大文字小文字を区別せずに前方一致を判定します。空の s には一致しません。
+func hasPrefixFold(s, prefix string) bool {
+	return s != "" && strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix))
+}
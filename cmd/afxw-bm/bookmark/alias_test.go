@@ -0,0 +1,148 @@
+package bookmark
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func aliasedBookmarks() []Bookmark {
+	return []Bookmark{
+		{Name: "work", Path: `C:\Work`, Alias: "w"},
+		{Name: "docs", Path: `C:\Users\Test\Documents`, Alias: "d"},
+		{Name: "downloads", Path: `C:\Users\Test\Downloads`},
+		{Name: "wiki", Path: `D:\Wiki`},
+		{Name: "project", Path: `D:\Project`, Alias: "proj"},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		alias       string
+		expected    int
+		expectedErr error
+	}{
+		{"w", 0, nil},            // エイリアスの完全一致を名前の前方一致より優先する
+		{"D", 1, nil},            // 大文字小文字を区別しない
+		{"wiki", 3, nil},         // 名前の完全一致
+		{"downl", 2, nil},        // 名前の前方一致
+		{"pro", 4, nil},          // エイリアスの前方一致
+		{"do", -1, ErrAmbiguous}, // docs と downloads
+		{"xyz", -1, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			got, err := Resolve(aliasedBookmarks(), tt.alias)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("エラー 期待: %v, 取得: %v", tt.expectedErr, err)
+			}
+			if got != tt.expected {
+				t.Errorf("期待: %d, 取得: %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestResolve_DuplicateAlias(t *testing.T) {
+	bookmarks := []Bookmark{
+		{Name: "a", Path: `C:\A`, Alias: "x"},
+		{Name: "b", Path: `C:\B`, Alias: "X"},
+	}
+	if _, err := Resolve(bookmarks, "x"); !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("期待: ErrAmbiguous, 取得: %v", err)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		alias    string
+		expected []string
+	}{
+		{"wrok", []string{"work"}},
+		{"projetc", []string{"project"}},
+		{"docz", []string{"docs"}},
+		{"zzzzzzzz", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			if got := Suggest(aliasedBookmarks(), tt.alias); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"work", "work", 0},
+		{"work", "wrok", 1}, // 隣り合う2文字の入れ替え
+		{"kitten", "sitting", 3},
+		{"作業", "作業場", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"|"+tt.b, func(t *testing.T) {
+			if got := editDistance(tt.a, tt.b); got != tt.expected {
+				t.Errorf("期待: %d, 取得: %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSetAlias(t *testing.T) {
+	testPath := filepath.Join(t.TempDir(), FileName)
+	for _, item := range []string{`C:\Users\Test\Dir1`, `C:\Users\Test\Dir2`} {
+		if err := Add(testPath, item); err != nil {
+			t.Fatalf("追加に失敗しました (%s): %v", item, err)
+		}
+	}
+
+	if err := SetAlias(testPath, "Dir1", "one"); err != nil {
+		t.Fatalf("エイリアスの設定に失敗しました: %v", err)
+	}
+	// 他のブックマークと同じエイリアスは設定できない
+	if err := SetAlias(testPath, "Dir2", "ONE"); !errors.Is(err, ErrAliasInUse) {
+		t.Errorf("期待: ErrAliasInUse, 取得: %v", err)
+	}
+	// エイリアスでもブックマークを指定できる
+	if err := SetAlias(testPath, "one", "first"); err != nil {
+		t.Fatalf("エイリアスの設定に失敗しました: %v", err)
+	}
+	if err := SetAlias(testPath, "Dir2", "two"); err != nil {
+		t.Fatalf("エイリアスの設定に失敗しました: %v", err)
+	}
+	if err := SetAlias(testPath, "two", ""); err != nil {
+		t.Fatalf("エイリアスの削除に失敗しました: %v", err)
+	}
+
+	bookmarks, err := Load(testPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if bookmarks[0].Alias != "first" || bookmarks[1].Alias != "" {
+		t.Errorf("期待: %q, %q, 取得: %q, %q", "first", "", bookmarks[0].Alias, bookmarks[1].Alias)
+	}
+}
+
+func TestFormat_WithAlias(t *testing.T) {
+	bookmarks := []Bookmark{
+		{Name: "work", Path: `C:\Work`, Alias: "w", Tags: []string{"daily"}},
+		{Name: "docs", Path: `C:\Docs`, Alias: "d"},
+	}
+
+	expected := []string{
+		`work  C:\Work  @w #daily`,
+		`docs  C:\Docs  @d`,
+	}
+	if got := Format(bookmarks); !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, got)
+	}
+}
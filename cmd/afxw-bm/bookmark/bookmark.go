@@ -1,32 +1,211 @@
+// Package bookmark はブックマークファイルの読み書きを提供します。
+//
+// ブックマークは TOML 形式のファイル（bookmarks.toml）に保存します。
+// 以前の1行1パスのテキスト形式（bookmarks.txt）は読み込み時に自動で移行します。
 package bookmark
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mattn/go-runewidth"
+	"github.com/tana9/afxw-tools/internal/fileutil"
+	"github.com/tana9/afxw-tools/internal/winpath"
 )
 
-// GetDefaultPath はブックマークファイルのデフォルトパスを返します。
-// 実行ファイルと同じディレクトリにある "bookmarks.txt" のパスを返します。
-func GetDefaultPath() (string, error) {
-	exe, err := os.Executable()
-	if err != nil {
-		return "", err
+const (
+	// FileName はブックマークファイルの名前です。
+	FileName = "bookmarks.toml"
+	// LegacyFileName は以前のテキスト形式のブックマークファイルの名前です。
+	LegacyFileName = "bookmarks.txt"
+)
+
+// FileMark はファインダーでファイルのブックマークの名前の前に付ける印です。
+const FileMark = "* "
+
+var (
+	// ErrNotFound は指定されたブックマークが見つからないことを表します。
+	ErrNotFound = errors.New("ブックマークが見つかりません")
+	// ErrAmbiguous は指定された名前のブックマークが複数あることを表します。
+	ErrAmbiguous = errors.New("同じ名前のブックマークが複数あります")
+)
+
+// Bookmark は1件のブックマークを表します。
+// Path は環境変数などを展開したパスです。ファイルに %USERPROFILE% などで書かれていた場合は、
+// 保存するときに元の形に戻します。
+type Bookmark struct {
+	Name     string    `toml:"name"`
+	Path     string    `toml:"path"`
+	File     bool      `toml:"file,omitempty"`  // ディレクトリではなくファイルを指す
+	Alias    string    `toml:"alias,omitempty"` // afxw-bm go で指定する短い名前
+	Group    string    `toml:"group,omitempty"` // "顧客A/案件X" のように / で区切ったグループ
+	Tags     []string  `toml:"tags,omitempty"`
+	Note     string    `toml:"note,omitempty"`
+	Created  time.Time `toml:"created"`             // 追加した日時
+	LastUsed time.Time `toml:"last_used,omitempty"` // 最後に移動した日時
+	UseCount int       `toml:"use_count,omitempty"` // 移動した回数
+
+	stored string // ファイルに書かれていた展開前のパス
+	source Source // 読み込んだファイル（LoadSources で読み込んだ場合）
+}
+
+// file はブックマークファイルの形式です。
+type file struct {
+	Bookmarks []Bookmark `toml:"bookmark"`
+}
+
+// New は dir を指すブックマークを作成します。名前はディレクトリ名になります。
+func New(dir string, now time.Time) Bookmark {
+	return Bookmark{Name: defaultName(dir), Path: dir, Created: now}
+}
+
+// defaultName はパスからブックマークの既定の名前を決めます。
+// ドライブのルートなど名前にできる要素がない場合はボリューム名を使います。
+func defaultName(path string) string {
+	if name := winpath.Base(path); name != `\` {
+		return name
 	}
-	return filepath.Join(filepath.Dir(exe), "bookmarks.txt"), nil
+	if vol := winpath.VolumeName(path); vol != "" {
+		return vol
+	}
+	return path
 }
 
 // Load は指定されたファイルからブックマークを読み込みます。
-// 重複のない文字列のスライスを返します。
-func Load(path string) ([]string, error) {
-	f, err := os.Open(path)
+// 同じパスを指すブックマークは最初のものだけを返します。
+// ファイルが存在せず、同じディレクトリにテキスト形式の bookmarks.txt がある場合は移行します。
+//
+// 書き込みは一時ファイルからの置き換えで行うため、読み込みはロックせずに行います。
+func Load(path string) ([]Bookmark, error) {
+	return loadFile(path, false)
+}
+
+// LoadReadOnly は Load と同じくブックマークを読み込みますが、ファイルを変更しません。
+// 同じディレクトリにテキスト形式の bookmarks.txt しかない場合は、移行せずにそのまま読み込みます。
+func LoadReadOnly(path string) ([]Bookmark, error) {
+	return loadFile(path, true)
+}
+
+// loadFile は path からブックマークを読み込みます。readOnly が false の場合は必要であれば移行します。
+func loadFile(path string, readOnly bool) ([]Bookmark, error) {
+	bookmarks, err := read(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) && isLegacy(path) {
+		return nil, fmt.Errorf("%w（テキスト形式の %s は直接読み込めません。afxw-bm で移行した %s を指定してください）", err, LegacyFileName, FileName)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return bookmarks, err
+	}
+	legacy := legacyPath(path)
+	if winpath.Equal(legacy, path) {
+		return []Bookmark{}, nil
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		return []Bookmark{}, nil
+	}
+
+	if readOnly {
+		paths, err := loadLegacy(legacy)
+		if err != nil {
+			return nil, err
+		}
+		return fromLegacy(paths, time.Now()), nil
+	}
+
+	// 移行はファイルを書き換えるため、ロックを取得してから行う
+	err = fileutil.WithLock(path, func() error {
+		var err error
+		bookmarks, err = load(path)
+		return err
+	})
+	return bookmarks, err
+}
+
+// isLegacy は path がテキスト形式のブックマークファイルの名前（拡張子が .txt）かを返します。
+func isLegacy(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), filepath.Ext(LegacyFileName))
+}
+
+// load はロックを取得した状態でブックマークを読み込みます。必要であれば移行します。
+func load(path string) ([]Bookmark, error) {
+	bookmarks, err := read(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return migrate(path)
+	}
+	return bookmarks, err
+}
+
+// read は TOML 形式のブックマークファイルを読み込みます。
+// ファイルが存在しない場合は fs.ErrNotExist で判定できるエラーを返します。
+func read(path string) ([]Bookmark, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ブックマークファイルの読み込みに失敗しました: %w", err)
+	}
+
+	var f file
+	if err := toml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("ブックマークファイルの解析に失敗しました: %w", err)
+	}
+	for i, b := range f.Bookmarks {
+		if expanded := Expand(b.Path); expanded != b.Path {
+			f.Bookmarks[i].Path = expanded
+			f.Bookmarks[i].stored = b.Path
+		}
+	}
+	return dedup(f.Bookmarks), nil
+}
+
+// legacyPath は path と同じディレクトリにあるテキスト形式のブックマークファイルのパスを返します。
+func legacyPath(path string) string {
+	return filepath.Join(filepath.Dir(path), LegacyFileName)
+}
+
+// migrate はテキスト形式のブックマークファイルを path に移行します。
+// 移行後のテキスト形式のファイルは ".bak" を付けた名前で残します。
+// ロックを取得した状態で呼び出す必要があります。
+func migrate(path string) ([]Bookmark, error) {
+	legacy := legacyPath(path)
+	paths, err := loadLegacy(legacy)
 	if os.IsNotExist(err) {
-		return []string{}, nil
+		return []Bookmark{}, nil
+	}
+	if err != nil {
+		return nil, err
 	}
+
+	bookmarks := fromLegacy(paths, time.Now())
+	if err := save(path, bookmarks); err != nil {
+		return nil, fmt.Errorf("ブックマークファイルの移行に失敗しました: %w", err)
+	}
+	if err := os.Rename(legacy, legacy+".bak"); err != nil {
+		return nil, fmt.Errorf("ブックマークファイルの移行に失敗しました: %w", err)
+	}
+	return bookmarks, nil
+}
+
+// fromLegacy はテキスト形式のファイルから読み込んだパスを、now に追加したブックマークにします。
+func fromLegacy(paths []string, now time.Time) []Bookmark {
+	bookmarks := make([]Bookmark, len(paths))
+	for i, p := range paths {
+		bookmarks[i] = New(p, now)
+	}
+	return bookmarks
+}
+
+// loadLegacy はテキスト形式のブックマークファイルを読み込みます。
+// ファイルが存在しない場合は os.IsNotExist で判定できるエラーを返します。
+func loadLegacy(path string) ([]string, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("ブックマークファイルのオープンに失敗しました: %w", err)
+		return nil, err
 	}
 	defer f.Close()
 
@@ -38,9 +217,10 @@ func Load(path string) ([]string, error) {
 		if line == "" {
 			continue
 		}
-		if _, ok := seen[line]; !ok {
+		k := winpath.Key(line)
+		if _, ok := seen[k]; !ok {
 			lines = append(lines, line)
-			seen[line] = struct{}{}
+			seen[k] = struct{}{}
 		}
 	}
 
@@ -50,32 +230,282 @@ func Load(path string) ([]string, error) {
 	return lines, nil
 }
 
-// Add は新しいブックマークをファイルに追記します。
+// dedup は同じパスを指すブックマークを取り除きます。最初に現れたものを残します。
+func dedup(bookmarks []Bookmark) []Bookmark {
+	seen := make(map[string]struct{}, len(bookmarks))
+	result := make([]Bookmark, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		k := winpath.Key(b.Path)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		result = append(result, b)
+	}
+	return result
+}
+
+// Save はブックマークを指定されたファイルに書き込みます。
+func Save(path string, bookmarks []Bookmark) error {
+	return fileutil.WithLock(path, func() error {
+		return save(path, bookmarks)
+	})
+}
+
+// save はロックを取得した状態でブックマークを書き込みます。
+func save(path string, bookmarks []Bookmark) error {
+	stored := make([]Bookmark, len(bookmarks))
+	for i, b := range bookmarks {
+		stored[i] = b
+		stored[i].Path = b.storedPath()
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(file{Bookmarks: stored}); err != nil {
+		return fmt.Errorf("ブックマークのエンコードに失敗しました: %w", err)
+	}
+	return fileutil.WriteAtomic(path, buf.Bytes())
+}
+
+// Update はブックマークを読み込み、fn で更新してから保存します。
+// 読み込みから保存までロックを保持するため、複数のプロセスから同時に呼び出しても更新が失われません。
+// fn がエラーを返した場合は保存しません。
+func Update(path string, fn func(bookmarks []Bookmark) ([]Bookmark, error)) error {
+	return fileutil.WithLock(path, func() error {
+		bookmarks, err := load(path)
+		if err != nil {
+			return err
+		}
+		bookmarks, err = fn(bookmarks)
+		if err != nil {
+			return err
+		}
+		return save(path, bookmarks)
+	})
+}
+
+// Index は dir を指すブックマークの位置を返します。見つからない場合は -1 を返します。
+func Index(bookmarks []Bookmark, dir string) int {
+	for i, b := range bookmarks {
+		if winpath.Equal(b.Path, dir) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Lookup は名前、エイリアスまたはパスが key に一致するブックマークの位置を返します。
+// パス、エイリアス、名前の順に一致を優先し、名前とエイリアスは大文字小文字を区別せずに比較します。
+// パスに含まれる環境変数などは展開してから比較します。
+// 名前が複数のブックマークに一致する場合は ErrAmbiguous を返します。
+func Lookup(bookmarks []Bookmark, key string) (int, error) {
+	if i := Index(bookmarks, Expand(key)); i >= 0 {
+		return i, nil
+	}
+	for _, field := range []func(Bookmark) string{aliasOf, nameOf} {
+		i, err := lookupBy(bookmarks, key, field)
+		if !errors.Is(err, ErrNotFound) {
+			return i, err
+		}
+	}
+	return -1, fmt.Errorf("%w: %s", ErrNotFound, key)
+}
+
+// aliasOf はブックマークのエイリアスを返します。
+func aliasOf(b Bookmark) string { return b.Alias }
+
+// nameOf はブックマークの名前を返します。
+func nameOf(b Bookmark) string { return b.Name }
+
+// lookupBy は field の値が key に大文字小文字を区別せずに一致するブックマークの位置を返します。
+func lookupBy(bookmarks []Bookmark, key string, field func(Bookmark) string) (int, error) {
+	found := -1
+	for i, b := range bookmarks {
+		if !strings.EqualFold(field(b), key) {
+			continue
+		}
+		if found >= 0 {
+			return -1, fmt.Errorf("%w: %s", ErrAmbiguous, key)
+		}
+		found = i
+	}
+	if found < 0 {
+		return -1, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return found, nil
+}
+
+// Add は新しいブックマークをファイルに追加します。
 // 重複するブックマークは追加しません。
-func Add(path string, newItem string) error {
+func Add(path string, dir string) error {
+	return AddToGroup(path, dir, "")
+}
+
+// AddToGroup は新しいブックマークを group に追加します。
+// dir にはディレクトリのほか、ファイルのパスも指定できます。
+// dir に %USERPROFILE% などの環境変数を含めると、ファイルにはその形のまま保存します。
+// 既に同じパスのブックマークがある場合は追加せず、group が空でなければそのグループに移動します。
+func AddToGroup(path string, dir string, group string) error {
+	isFile := isRegularFile(Expand(dir))
+
 	// Windowsでの一貫性のため、パス区切り文字をバックスラッシュに正規化します
-	newItem = filepath.Clean(newItem)
+	stored := winpath.Clean(dir)
+	dir = Expand(stored)
+	group = CleanGroup(group)
 
-	lines, err := Load(path)
-	if err != nil {
-		return err
-	}
+	return Update(path, func(bookmarks []Bookmark) ([]Bookmark, error) {
+		if i := Index(bookmarks, dir); i >= 0 {
+			if group != "" {
+				bookmarks[i].Group = group
+			}
+			if stored != dir {
+				bookmarks[i].stored = stored
+			}
+			return bookmarks, nil
+		}
+		b := New(dir, time.Now())
+		b.File = isFile
+		b.Group = group
+		if stored != dir {
+			b.stored = stored
+		}
+		return append(bookmarks, b), nil
+	})
+}
+
+// isRegularFile は path がディレクトリ以外の既存のファイルかを返します。
+func isRegularFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// Remove は名前またはパスが key に一致するブックマークを削除し、削除したブックマークを返します。
+func Remove(path string, key string) (Bookmark, error) {
+	var removed Bookmark
+	err := Update(path, func(bookmarks []Bookmark) ([]Bookmark, error) {
+		i, err := Lookup(bookmarks, key)
+		if err != nil {
+			return nil, err
+		}
+		removed = bookmarks[i]
+		return append(bookmarks[:i], bookmarks[i+1:]...), nil
+	})
+	return removed, err
+}
+
+// Rename は名前またはパスが key に一致するブックマークの名前を name に変更します。
+func Rename(path string, key string, name string) error {
+	return Update(path, func(bookmarks []Bookmark) ([]Bookmark, error) {
+		i, err := Lookup(bookmarks, key)
+		if err != nil {
+			return nil, err
+		}
+		bookmarks[i].Name = name
+		return bookmarks, nil
+	})
+}
+
+// Relocate は dir を指すブックマークのパスを newDir に変更します。
+// 名前やエイリアス、使用履歴などはそのまま残します。
+// 元のパスを環境変数を使った形で保存していた場合は、新しいパスも環境変数を使った形で保存します。
+func Relocate(path string, dir string, newDir string) error {
+	return Update(path, func(bookmarks []Bookmark) ([]Bookmark, error) {
+		i := Index(bookmarks, dir)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, dir)
+		}
+		if j := Index(bookmarks, newDir); j >= 0 && j != i {
+			return nil, fmt.Errorf("移動先は既にブックマークされています: %s", newDir)
+		}
+		portable := bookmarks[i].stored != ""
+		bookmarks[i].Path = newDir
+		bookmarks[i].stored = ""
+		if p := Portable(newDir); portable && p != newDir {
+			bookmarks[i].stored = p
+		}
+		return bookmarks, nil
+	})
+}
 
-	for _, line := range lines {
-		if strings.EqualFold(line, newItem) { // Windowsパスの大文字小文字を区別しない比較
-			return nil // 既に存在する場合は何もしない
+// Prune は exists が false を返すブックマークを削除し、削除したブックマークを返します。
+// dryRun が true の場合はファイルを変更せずに削除対象だけを返します。
+func Prune(path string, exists func(dir string) bool, dryRun bool) ([]Bookmark, error) {
+	var removed []Bookmark
+	split := func(bookmarks []Bookmark) []Bookmark {
+		kept := make([]Bookmark, 0, len(bookmarks))
+		for _, b := range bookmarks {
+			if exists(b.Path) {
+				kept = append(kept, b)
+			} else {
+				removed = append(removed, b)
+			}
 		}
+		return kept
 	}
 
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("追記用ブックマークファイルのオープンに失敗しました: %w", err)
+	if dryRun {
+		bookmarks, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		split(bookmarks)
+		return removed, nil
 	}
-	defer f.Close()
 
-	if _, err := f.WriteString(newItem + "\n"); err != nil {
-		return fmt.Errorf("ブックマークファイルへの書き込みに失敗しました: %w", err)
+	err := Update(path, func(bookmarks []Bookmark) ([]Bookmark, error) {
+		return split(bookmarks), nil
+	})
+	return removed, err
+}
+
+// Touch は dir を指すブックマークへの移動を記録し、移動回数を1増やします。
+// 該当するブックマークがない場合は何もしません。
+func Touch(path string, dir string, now time.Time) error {
+	return Update(path, func(bookmarks []Bookmark) ([]Bookmark, error) {
+		if i := Index(bookmarks, dir); i >= 0 {
+			bookmarks[i].LastUsed = now
+			bookmarks[i].UseCount++
+		}
+		return bookmarks, nil
+	})
+}
+
+// Format はファインダーに表示する "名前  パス  [読み込み元] @エイリアス #タグ" 形式の文字列を返します。
+// グループに属するブックマークは名前を "グループ/名前" と表示します。
+// ファイルのブックマークはディレクトリと区別できるように、名前の前に FileMark を付けます。
+// 読み込み元は LoadSources で名前を付けたファイルから読み込んだ場合だけ表示します。
+// 名前の列は表示幅を揃えます。
+func Format(bookmarks []Bookmark) []string {
+	names := make([]string, len(bookmarks))
+	width := 0
+	for i, b := range bookmarks {
+		names[i] = b.Name
+		if g := CleanGroup(b.Group); g != "" {
+			names[i] = JoinGroup(g, b.Name)
+		}
+		if b.File {
+			names[i] = FileMark + names[i]
+		}
+		width = max(width, runewidth.StringWidth(names[i]))
 	}
 
-	return nil
+	lines := make([]string, len(bookmarks))
+	for i, b := range bookmarks {
+		line := runewidth.FillRight(names[i], width) + "  " + b.Path
+		var notes []string
+		if b.source.Label != "" {
+			notes = append(notes, "["+b.source.Label+"]")
+		}
+		if b.Alias != "" {
+			notes = append(notes, "@"+b.Alias)
+		}
+		for _, tag := range b.Tags {
+			notes = append(notes, "#"+tag)
+		}
+		if len(notes) > 0 {
+			line += "  " + strings.Join(notes, " ")
+		}
+		lines[i] = line
+	}
+	return lines
 }
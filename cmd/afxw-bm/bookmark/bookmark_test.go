@@ -1,154 +1,271 @@
 package bookmark
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
+// paths はブックマークのパスの一覧を返します。
+func paths(bookmarks []Bookmark) []string {
+	result := make([]string, len(bookmarks))
+	for i, b := range bookmarks {
+		result[i] = b.Path
+	}
+	return result
+}
+
 func TestLoad_NonExistentFile(t *testing.T) {
 	// 存在しないファイルパスを指定
 	tmpDir := t.TempDir()
-	nonExistentPath := filepath.Join(tmpDir, "non_existent.txt")
+	nonExistentPath := filepath.Join(tmpDir, "non_existent.toml")
 
 	// 存在しないファイルの場合、空のスライスを返すことを確認
-	dirs, err := Load(nonExistentPath)
+	bookmarks, err := Load(nonExistentPath)
 	if err != nil {
 		t.Fatalf("エラーが発生しました: %v", err)
 	}
 
-	if len(dirs) != 0 {
-		t.Errorf("期待: 空のスライス, 取得: %v", dirs)
+	if len(bookmarks) != 0 {
+		t.Errorf("期待: 空のスライス, 取得: %v", bookmarks)
 	}
 }
 
 func TestLoad_EmptyFile(t *testing.T) {
 	// 空のファイルを作成
 	tmpDir := t.TempDir()
-	emptyPath := filepath.Join(tmpDir, "empty.txt")
+	emptyPath := filepath.Join(tmpDir, "empty.toml")
 
 	if err := os.WriteFile(emptyPath, []byte(""), 0644); err != nil {
 		t.Fatalf("空のファイル作成に失敗しました: %v", err)
 	}
 
-	dirs, err := Load(emptyPath)
+	bookmarks, err := Load(emptyPath)
 	if err != nil {
 		t.Fatalf("エラーが発生しました: %v", err)
 	}
 
-	if len(dirs) != 0 {
-		t.Errorf("期待: 空のスライス, 取得: %v", dirs)
+	if len(bookmarks) != 0 {
+		t.Errorf("期待: 空のスライス, 取得: %v", bookmarks)
 	}
 }
 
 func TestLoad_WithContent(t *testing.T) {
 	tmpDir := t.TempDir()
-	testPath := filepath.Join(tmpDir, "bookmarks.txt")
-
-	content := `C:\Users\Test\Dir1
-C:\Users\Test\Dir2
-C:\Users\Test\Dir3
+	testPath := filepath.Join(tmpDir, FileName)
+
+	content := `[[bookmark]]
+name = "作業"
+path = 'C:\Users\Test\Work'
+tags = ["work", "daily"]
+note = "毎日使う"
+created = 2026-01-02T03:04:05Z
+last_used = 2026-02-03T04:05:06Z
+use_count = 3
+
+[[bookmark]]
+name = "Dir2"
+path = 'C:\Users\Test\Dir2'
+created = 2026-01-02T03:04:05Z
 `
 	if err := os.WriteFile(testPath, []byte(content), 0644); err != nil {
 		t.Fatalf("テストファイル作成に失敗しました: %v", err)
 	}
 
-	dirs, err := Load(testPath)
+	bookmarks, err := Load(testPath)
 	if err != nil {
 		t.Fatalf("エラーが発生しました: %v", err)
 	}
 
-	expected := 3
-	if len(dirs) != expected {
-		t.Errorf("期待: %d個, 取得: %d個", expected, len(dirs))
+	expected := []Bookmark{
+		{
+			Name:     "作業",
+			Path:     `C:\Users\Test\Work`,
+			Tags:     []string{"work", "daily"},
+			Note:     "毎日使う",
+			Created:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			LastUsed: time.Date(2026, 2, 3, 4, 5, 6, 0, time.UTC),
+			UseCount: 3,
+		},
+		{
+			Name:    "Dir2",
+			Path:    `C:\Users\Test\Dir2`,
+			Created: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
 	}
+	if len(bookmarks) != len(expected) {
+		t.Fatalf("期待: %d個, 取得: %d個", len(expected), len(bookmarks))
+	}
+	for i := range expected {
+		got, want := bookmarks[i], expected[i]
+		if got.Name != want.Name || got.Path != want.Path || got.Note != want.Note || got.UseCount != want.UseCount ||
+			!reflect.DeepEqual(got.Tags, want.Tags) || !got.Created.Equal(want.Created) || !got.LastUsed.Equal(want.LastUsed) {
+			t.Errorf("[%d] 期待: %+v, 取得: %+v", i, want, got)
+		}
+	}
+}
 
-	if dirs[0] != `C:\Users\Test\Dir1` {
-		t.Errorf("期待: C:\\Users\\Test\\Dir1, 取得: %s", dirs[0])
+func TestLoad_WithEquivalentPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	testPath := filepath.Join(tmpDir, FileName)
+
+	// 大文字小文字、末尾の区切り、区切り文字の違いは同じパスとして扱う
+	bookmarks := []Bookmark{
+		{Name: "1", Path: `C:\Users\Test\Dir1`},
+		{Name: "2", Path: `c:\users\test\dir1\`},
+		{Name: "3", Path: `C:/Users/Test/Dir1`},
+		{Name: "4", Path: `C:\Users\Test\Dir2`},
+	}
+	if err := Save(testPath, bookmarks); err != nil {
+		t.Fatalf("保存に失敗しました: %v", err)
+	}
+
+	got, err := Load(testPath)
+	if err != nil {
+		t.Fatalf("エラーが発生しました: %v", err)
+	}
+
+	expected := []string{`C:\Users\Test\Dir1`, `C:\Users\Test\Dir2`}
+	if !reflect.DeepEqual(paths(got), expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, paths(got))
 	}
 }
 
-func TestLoad_WithDuplicates(t *testing.T) {
+func TestLoad_InvalidFile(t *testing.T) {
 	tmpDir := t.TempDir()
-	testPath := filepath.Join(tmpDir, "bookmarks.txt")
+	testPath := filepath.Join(tmpDir, FileName)
+
+	if err := os.WriteFile(testPath, []byte("[[bookmark]\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
 
+	if _, err := Load(testPath); err == nil {
+		t.Error("エラーが期待されましたが、nilが返りました")
+	}
+}
+
+func TestLoad_MigratesLegacyFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	testPath := filepath.Join(tmpDir, FileName)
+	legacyPath := filepath.Join(tmpDir, LegacyFileName)
+
+	// 重複と空行は移行時に取り除く
 	content := `C:\Users\Test\Dir1
+
 C:\Users\Test\Dir2
 C:\Users\Test\Dir1
-C:\Users\Test\Dir3
-C:\Users\Test\Dir2
+C:\
 `
-	if err := os.WriteFile(testPath, []byte(content), 0644); err != nil {
+	if err := os.WriteFile(legacyPath, []byte(content), 0644); err != nil {
 		t.Fatalf("テストファイル作成に失敗しました: %v", err)
 	}
 
-	dirs, err := Load(testPath)
+	bookmarks, err := Load(testPath)
 	if err != nil {
 		t.Fatalf("エラーが発生しました: %v", err)
 	}
 
-	// 重複を除外して3個になることを確認
-	expected := 3
-	if len(dirs) != expected {
-		t.Errorf("期待: %d個, 取得: %d個", expected, len(dirs))
+	expectedPaths := []string{`C:\Users\Test\Dir1`, `C:\Users\Test\Dir2`, `C:\`}
+	if !reflect.DeepEqual(paths(bookmarks), expectedPaths) {
+		t.Errorf("期待: %q, 取得: %q", expectedPaths, paths(bookmarks))
+	}
+	expectedNames := []string{"Dir1", "Dir2", "C:"}
+	for i, b := range bookmarks {
+		if b.Name != expectedNames[i] {
+			t.Errorf("[%d] 名前 期待: %q, 取得: %q", i, expectedNames[i], b.Name)
+		}
+		if b.Created.IsZero() {
+			t.Errorf("[%d] 追加日時が設定されていません", i)
+		}
 	}
-}
 
-func TestLoad_WithEmptyLines(t *testing.T) {
-	tmpDir := t.TempDir()
-	testPath := filepath.Join(tmpDir, "bookmarks.txt")
+	// 新しい形式のファイルが作られ、古いファイルは .bak として残る
+	if _, err := os.Stat(testPath); err != nil {
+		t.Errorf("移行後のファイルがありません: %v", err)
+	}
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Errorf("古いファイルが残っています: %v", err)
+	}
+	if _, err := os.Stat(legacyPath + ".bak"); err != nil {
+		t.Errorf("古いファイルのバックアップがありません: %v", err)
+	}
 
-	content := `C:\Users\Test\Dir1
+	// 2回目以降は移行済みのファイルを読み込む
+	again, err := Load(testPath)
+	if err != nil {
+		t.Fatalf("エラーが発生しました: %v", err)
+	}
+	if !reflect.DeepEqual(paths(again), expectedPaths) {
+		t.Errorf("期待: %q, 取得: %q", expectedPaths, paths(again))
+	}
+}
 
-C:\Users\Test\Dir2
+func TestSave_RoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	testPath := filepath.Join(tmpDir, "sub", FileName)
 
-C:\Users\Test\Dir3
-`
-	if err := os.WriteFile(testPath, []byte(content), 0644); err != nil {
-		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	now := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	bookmarks := []Bookmark{
+		{Name: "記号", Path: `C:\Users\Test\$Dir "1"`, Tags: []string{"a"}, Note: "メモ", Created: now, LastUsed: now, UseCount: 2},
+		{Name: "ルート", Path: `D:\`, Created: now},
+	}
+	if err := Save(testPath, bookmarks); err != nil {
+		t.Fatalf("保存に失敗しました: %v", err)
 	}
 
-	dirs, err := Load(testPath)
+	got, err := Load(testPath)
 	if err != nil {
-		t.Fatalf("エラーが発生しました: %v", err)
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if !reflect.DeepEqual(got, bookmarks) {
+		t.Errorf("期待: %+v, 取得: %+v", bookmarks, got)
 	}
 
-	// 空行を除外して3個になることを確認
-	expected := 3
-	if len(dirs) != expected {
-		t.Errorf("期待: %d個, 取得: %d個", expected, len(dirs))
+	// 一時ファイルが残っていないこと
+	tmps, err := filepath.Glob(filepath.Join(filepath.Dir(testPath), "*.tmp"))
+	if err != nil {
+		t.Fatalf("ディレクトリの読み込みに失敗しました: %v", err)
+	}
+	if len(tmps) != 0 {
+		t.Errorf("一時ファイルが残っています: %q", tmps)
 	}
 }
 
 func TestAdd_NewItem(t *testing.T) {
 	tmpDir := t.TempDir()
-	testPath := filepath.Join(tmpDir, "bookmarks.txt")
+	testPath := filepath.Join(tmpDir, FileName)
 
-	newItem := `C:\Users\Test\NewDir`
+	newItem := `C:/Users/Test/NewDir/`
 	if err := Add(testPath, newItem); err != nil {
 		t.Fatalf("追加に失敗しました: %v", err)
 	}
 
 	// ファイルから読み込んで確認
-	dirs, err := Load(testPath)
+	bookmarks, err := Load(testPath)
 	if err != nil {
 		t.Fatalf("読み込みに失敗しました: %v", err)
 	}
 
-	if len(dirs) != 1 {
-		t.Errorf("期待: 1個, 取得: %d個", len(dirs))
+	if len(bookmarks) != 1 {
+		t.Fatalf("期待: 1個, 取得: %d個", len(bookmarks))
 	}
 
-	// filepath.Cleanされた結果を期待
-	expectedPath := filepath.Clean(newItem)
-	if dirs[0] != expectedPath {
-		t.Errorf("期待: %s, 取得: %s", expectedPath, dirs[0])
+	// 区切り文字が正規化された結果を期待
+	if bookmarks[0].Path != `C:\Users\Test\NewDir` {
+		t.Errorf("期待: %s, 取得: %s", `C:\Users\Test\NewDir`, bookmarks[0].Path)
+	}
+	if bookmarks[0].Name != "NewDir" {
+		t.Errorf("期待: NewDir, 取得: %s", bookmarks[0].Name)
 	}
 }
 
 func TestAdd_DuplicateItem(t *testing.T) {
 	tmpDir := t.TempDir()
-	testPath := filepath.Join(tmpDir, "bookmarks.txt")
+	testPath := filepath.Join(tmpDir, FileName)
 
 	item := `C:\Users\Test\Dir1`
 
@@ -163,20 +280,20 @@ func TestAdd_DuplicateItem(t *testing.T) {
 	}
 
 	// ファイルから読み込んで確認
-	dirs, err := Load(testPath)
+	bookmarks, err := Load(testPath)
 	if err != nil {
 		t.Fatalf("読み込みに失敗しました: %v", err)
 	}
 
 	// 重複が除外されて1個のみになることを確認
-	if len(dirs) != 1 {
-		t.Errorf("期待: 1個, 取得: %d個", len(dirs))
+	if len(bookmarks) != 1 {
+		t.Errorf("期待: 1個, 取得: %d個", len(bookmarks))
 	}
 }
 
 func TestAdd_CaseInsensitive(t *testing.T) {
 	tmpDir := t.TempDir()
-	testPath := filepath.Join(tmpDir, "bookmarks.txt")
+	testPath := filepath.Join(tmpDir, FileName)
 
 	item1 := `C:\Users\Test\Dir1`
 	item2 := `c:\users\test\dir1` // 大文字小文字が異なる
@@ -192,20 +309,20 @@ func TestAdd_CaseInsensitive(t *testing.T) {
 	}
 
 	// ファイルから読み込んで確認
-	dirs, err := Load(testPath)
+	bookmarks, err := Load(testPath)
 	if err != nil {
 		t.Fatalf("読み込みに失敗しました: %v", err)
 	}
 
 	// Windowsでは大文字小文字を区別しないため、1個のみになることを確認
-	if len(dirs) != 1 {
-		t.Errorf("期待: 1個（大文字小文字の区別なし）, 取得: %d個", len(dirs))
+	if len(bookmarks) != 1 {
+		t.Errorf("期待: 1個（大文字小文字の区別なし）, 取得: %d個", len(bookmarks))
 	}
 }
 
 func TestAdd_MultipleItems(t *testing.T) {
 	tmpDir := t.TempDir()
-	testPath := filepath.Join(tmpDir, "bookmarks.txt")
+	testPath := filepath.Join(tmpDir, FileName)
 
 	items := []string{
 		`C:\Users\Test\Dir1`,
@@ -220,13 +337,283 @@ func TestAdd_MultipleItems(t *testing.T) {
 		}
 	}
 
-	// ファイルから読み込んで確認
-	dirs, err := Load(testPath)
+	// ファイルから読み込んで確認（追加した順序を保持する）
+	bookmarks, err := Load(testPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+
+	if !reflect.DeepEqual(paths(bookmarks), items) {
+		t.Errorf("期待: %q, 取得: %q", items, paths(bookmarks))
+	}
+}
+
+func TestAdd_File(t *testing.T) {
+	tmpDir := t.TempDir()
+	testPath := filepath.Join(tmpDir, FileName)
+	file := filepath.Join(tmpDir, "report.xlsx")
+	if err := os.WriteFile(file, []byte("data"), 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	for _, path := range []string{file, tmpDir} {
+		if err := Add(testPath, path); err != nil {
+			t.Fatalf("追加に失敗しました: %v", err)
+		}
+	}
+
+	bookmarks, err := Load(testPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(bookmarks) != 2 {
+		t.Fatalf("期待: 2件, 取得: %v", paths(bookmarks))
+	}
+	if !bookmarks[0].File || bookmarks[0].Name != "report.xlsx" {
+		t.Errorf("ファイルのブックマークが期待されましたが、%+v が返りました", bookmarks[0])
+	}
+	if bookmarks[1].File {
+		t.Errorf("ディレクトリのブックマークが期待されましたが、%+v が返りました", bookmarks[1])
+	}
+}
+
+func TestTouch(t *testing.T) {
+	tmpDir := t.TempDir()
+	testPath := filepath.Join(tmpDir, FileName)
+
+	for _, item := range []string{`C:\Users\Test\Dir1`, `C:\Users\Test\Dir2`} {
+		if err := Add(testPath, item); err != nil {
+			t.Fatalf("追加に失敗しました (%s): %v", item, err)
+		}
+	}
+
+	now := time.Date(2026, 5, 6, 7, 8, 9, 0, time.UTC)
+	for range 2 {
+		if err := Touch(testPath, `c:\users\test\dir2\`, now); err != nil {
+			t.Fatalf("記録に失敗しました: %v", err)
+		}
+	}
+	// ブックマークにないパスは無視する
+	if err := Touch(testPath, `C:\Other`, now); err != nil {
+		t.Fatalf("記録に失敗しました: %v", err)
+	}
+
+	bookmarks, err := Load(testPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(bookmarks) != 2 {
+		t.Fatalf("期待: 2個, 取得: %d個", len(bookmarks))
+	}
+	if bookmarks[0].UseCount != 0 || !bookmarks[0].LastUsed.IsZero() {
+		t.Errorf("Dir1 は変更されるべきではありません: %+v", bookmarks[0])
+	}
+	if bookmarks[1].UseCount != 2 || !bookmarks[1].LastUsed.Equal(now) {
+		t.Errorf("期待: 2回 %v, 取得: %d回 %v", now, bookmarks[1].UseCount, bookmarks[1].LastUsed)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	bookmarks := []Bookmark{
+		{Name: "work", Path: `C:\Work`, Tags: []string{"daily", "job"}},
+		{Name: "ドキュメント", Path: `C:\Users\Test\Documents`},
+		{Name: "", Path: `D:\`},
+	}
+
+	expected := []string{
+		`work          C:\Work  #daily #job`,
+		`ドキュメント  C:\Users\Test\Documents`,
+		`              D:\`,
+	}
+	if got := Format(bookmarks); !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, got)
+	}
+}
+
+func TestFormat_File(t *testing.T) {
+	bookmarks := []Bookmark{
+		{Name: "work", Path: `C:\Work`},
+		{Name: "report.xlsx", Path: `C:\Work\report.xlsx`, File: true, Group: "資料"},
+	}
+
+	expected := []string{
+		`work                C:\Work`,
+		`* 資料/report.xlsx  C:\Work\report.xlsx`,
+	}
+	if got := Format(bookmarks); !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, got)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	bookmarks := []Bookmark{
+		{Name: "work", Path: `C:\Work`},
+		{Name: "docs", Path: `C:\Users\Test\Documents`},
+		{Name: "dup", Path: `D:\One`},
+		{Name: "DUP", Path: `D:\Two`},
+		{Name: `C:\Work`, Path: `E:\Named`},
+	}
+
+	tests := []struct {
+		key         string
+		expected    int
+		expectedErr error
+	}{
+		{"work", 0, nil},
+		{"Docs", 1, nil},
+		{`c:\users\test\documents\`, 1, nil},
+		{`C:\Work`, 0, nil}, // パスの一致を名前より優先する
+		{"dup", -1, ErrAmbiguous},
+		{"none", -1, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := Lookup(bookmarks, tt.key)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("エラー 期待: %v, 取得: %v", tt.expectedErr, err)
+			}
+			if got != tt.expected {
+				t.Errorf("期待: %d, 取得: %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	tmpDir := t.TempDir()
+	testPath := filepath.Join(tmpDir, FileName)
+
+	for _, item := range []string{`C:\Users\Test\Dir1`, `C:\Users\Test\Dir2`, `C:\Users\Test\Dir3`} {
+		if err := Add(testPath, item); err != nil {
+			t.Fatalf("追加に失敗しました (%s): %v", item, err)
+		}
+	}
+
+	removed, err := Remove(testPath, "dir2")
+	if err != nil {
+		t.Fatalf("削除に失敗しました: %v", err)
+	}
+	if removed.Path != `C:\Users\Test\Dir2` {
+		t.Errorf("期待: %s, 取得: %s", `C:\Users\Test\Dir2`, removed.Path)
+	}
+
+	if _, err := Remove(testPath, "dir2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("期待: ErrNotFound, 取得: %v", err)
+	}
+
+	bookmarks, err := Load(testPath)
 	if err != nil {
 		t.Fatalf("読み込みに失敗しました: %v", err)
 	}
+	expected := []string{`C:\Users\Test\Dir1`, `C:\Users\Test\Dir3`}
+	if !reflect.DeepEqual(paths(bookmarks), expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, paths(bookmarks))
+	}
+}
+
+func TestRename(t *testing.T) {
+	tmpDir := t.TempDir()
+	testPath := filepath.Join(tmpDir, FileName)
+
+	if err := Add(testPath, `C:\Users\Test\Dir1`); err != nil {
+		t.Fatalf("追加に失敗しました: %v", err)
+	}
+
+	if err := Rename(testPath, `C:\Users\Test\Dir1`, "作業"); err != nil {
+		t.Fatalf("名前の変更に失敗しました: %v", err)
+	}
+	if err := Rename(testPath, "none", "x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("期待: ErrNotFound, 取得: %v", err)
+	}
+
+	bookmarks, err := Load(testPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if bookmarks[0].Name != "作業" {
+		t.Errorf("期待: 作業, 取得: %s", bookmarks[0].Name)
+	}
+}
+
+func TestRelocate(t *testing.T) {
+	t.Setenv("USERPROFILE", `C:\Users\Test`)
+	testPath := filepath.Join(t.TempDir(), FileName)
+
+	for _, dir := range []string{`%USERPROFILE%\old\app`, `D:\old\tool`, `D:\new\other`} {
+		if err := Add(testPath, dir); err != nil {
+			t.Fatalf("追加に失敗しました: %v", err)
+		}
+	}
+	if err := SetAlias(testPath, `D:\old\tool`, "t"); err != nil {
+		t.Fatalf("エイリアスの設定に失敗しました: %v", err)
+	}
+
+	if err := Relocate(testPath, `C:\Users\Test\old\app`, `C:\Users\Test\new\app`); err != nil {
+		t.Fatalf("移動に失敗しました: %v", err)
+	}
+	if err := Relocate(testPath, `D:\old\tool`, `D:\new\tool`); err != nil {
+		t.Fatalf("移動に失敗しました: %v", err)
+	}
+	if err := Relocate(testPath, `D:\new\tool`, `D:\new\other`); err == nil {
+		t.Error("既にブックマークされているパスへの移動はエラーが期待されました")
+	}
+	if err := Relocate(testPath, `D:\none`, `D:\new\none`); !errors.Is(err, ErrNotFound) {
+		t.Errorf("期待: ErrNotFound, 取得: %v", err)
+	}
+
+	// 環境変数を使って保存していたパスは、移動後も環境変数を使って保存する
+	expectedStored := []string{`%USERPROFILE%\new\app`, `D:\new\tool`, `D:\new\other`}
+	if got := storedPaths(t, testPath); !reflect.DeepEqual(got, expectedStored) {
+		t.Errorf("期待: %q, 取得: %q", expectedStored, got)
+	}
+	bookmarks, err := Load(testPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if bookmarks[1].Alias != "t" || bookmarks[1].Name != "tool" {
+		t.Errorf("名前やエイリアスは変更しないべきです: %+v", bookmarks[1])
+	}
+}
+
+func TestPrune(t *testing.T) {
+	items := []string{`C:\Exists1`, `C:\Gone1`, `C:\Exists2`, `C:\Gone2`}
+	exists := func(dir string) bool { return strings.Contains(dir, "Exists") }
+
+	tests := []struct {
+		name          string
+		dryRun        bool
+		expectedPaths []string
+	}{
+		{"削除する", false, []string{`C:\Exists1`, `C:\Exists2`}},
+		{"dry-run では変更しない", true, items},
+	}
 
-	if len(dirs) != len(items) {
-		t.Errorf("期待: %d個, 取得: %d個", len(items), len(dirs))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testPath := filepath.Join(t.TempDir(), FileName)
+			for _, item := range items {
+				if err := Add(testPath, item); err != nil {
+					t.Fatalf("追加に失敗しました (%s): %v", item, err)
+				}
+			}
+
+			removed, err := Prune(testPath, exists, tt.dryRun)
+			if err != nil {
+				t.Fatalf("整理に失敗しました: %v", err)
+			}
+			expectedRemoved := []string{`C:\Gone1`, `C:\Gone2`}
+			if !reflect.DeepEqual(paths(removed), expectedRemoved) {
+				t.Errorf("削除対象 期待: %q, 取得: %q", expectedRemoved, paths(removed))
+			}
+
+			bookmarks, err := Load(testPath)
+			if err != nil {
+				t.Fatalf("読み込みに失敗しました: %v", err)
+			}
+			if !reflect.DeepEqual(paths(bookmarks), tt.expectedPaths) {
+				t.Errorf("期待: %q, 取得: %q", tt.expectedPaths, paths(bookmarks))
+			}
+		})
 	}
 }
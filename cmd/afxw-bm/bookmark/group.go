@@ -0,0 +1,81 @@
+package bookmark
+
+import "strings"
+
+// GroupSeparator はグループの階層の区切り文字です。
+const GroupSeparator = "/"
+
+// CleanGroup はグループ名を正規化します。
+// 区切り文字の \ を / に揃え、空の階層と前後の空白を取り除きます。
+func CleanGroup(group string) string {
+	var parts []string
+	for _, p := range strings.Split(strings.ReplaceAll(group, `\`, GroupSeparator), GroupSeparator) {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, GroupSeparator)
+}
+
+// ParentGroup はグループの1つ上の階層を返します。最上位のグループの場合は空文字列を返します。
+func ParentGroup(group string) string {
+	if i := strings.LastIndex(group, GroupSeparator); i >= 0 {
+		return group[:i]
+	}
+	return ""
+}
+
+// JoinGroup は親のグループと子のグループ名を連結します。
+func JoinGroup(parent, child string) string {
+	if parent == "" {
+		return child
+	}
+	return parent + GroupSeparator + child
+}
+
+// HasGroups はいずれかのブックマークがグループに属しているかを返します。
+func HasGroups(bookmarks []Bookmark) bool {
+	for _, b := range bookmarks {
+		if CleanGroup(b.Group) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// SubGroups は parent の直下にあるグループ名を最初に現れた順で返します。
+// parent が空の場合は最上位のグループを返します。
+func SubGroups(bookmarks []Bookmark, parent string) []string {
+	var groups []string
+	seen := make(map[string]struct{})
+	for _, b := range bookmarks {
+		rest, ok := strings.CutPrefix(CleanGroup(b.Group), parent)
+		if !ok || rest == "" {
+			continue
+		}
+		if parent != "" {
+			// "顧客A" と "顧客AB" を区別するため、区切り文字で始まる場合だけを子とみなす
+			if rest, ok = strings.CutPrefix(rest, GroupSeparator); !ok {
+				continue
+			}
+		}
+		name, _, _ := strings.Cut(rest, GroupSeparator)
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			groups = append(groups, name)
+		}
+	}
+	return groups
+}
+
+// InGroup は group の直下にあるブックマークの位置を返します。
+// group が空の場合はどのグループにも属していないブックマークを返します。
+func InGroup(bookmarks []Bookmark, group string) []int {
+	var indices []int
+	for i, b := range bookmarks {
+		if CleanGroup(b.Group) == group {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
@@ -0,0 +1,138 @@
+package bookmark
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCleanGroup(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"顧客A", "顧客A"},
+		{"顧客A/案件X", "顧客A/案件X"},
+		{`顧客A\案件X`, "顧客A/案件X"},
+		{"/顧客A//案件X/", "顧客A/案件X"},
+		{" 顧客A / 案件X ", "顧客A/案件X"},
+		{"/", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := CleanGroup(tt.input); got != tt.expected {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParentGroup(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"顧客A", ""},
+		{"顧客A/案件X", "顧客A"},
+		{"顧客A/案件X/資料", "顧客A/案件X"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := ParentGroup(tt.input); got != tt.expected {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSubGroupsAndInGroup(t *testing.T) {
+	bookmarks := []Bookmark{
+		{Name: "home", Path: `C:\Home`},
+		{Name: "x", Path: `C:\A\X`, Group: "顧客A/案件X"},
+		{Name: "a", Path: `C:\A`, Group: "顧客A"},
+		{Name: "y", Path: `C:\A\Y`, Group: "顧客A/案件Y"},
+		{Name: "x2", Path: `C:\A\X2`, Group: "/顧客A/案件X/"},
+		{Name: "ab", Path: `C:\AB`, Group: "顧客AB"},
+		{Name: "b", Path: `C:\B`, Group: "顧客B"},
+	}
+
+	tests := []struct {
+		group           string
+		expectedGroups  []string
+		expectedIndices []int
+	}{
+		{"", []string{"顧客A", "顧客AB", "顧客B"}, []int{0}},
+		{"顧客A", []string{"案件X", "案件Y"}, []int{2}},
+		{"顧客A/案件X", nil, []int{1, 4}},
+		{"顧客B", nil, []int{6}},
+		{"なし", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.group, func(t *testing.T) {
+			if got := SubGroups(bookmarks, tt.group); !reflect.DeepEqual(got, tt.expectedGroups) {
+				t.Errorf("SubGroups 期待: %q, 取得: %q", tt.expectedGroups, got)
+			}
+			if got := InGroup(bookmarks, tt.group); !reflect.DeepEqual(got, tt.expectedIndices) {
+				t.Errorf("InGroup 期待: %v, 取得: %v", tt.expectedIndices, got)
+			}
+		})
+	}
+
+	if !HasGroups(bookmarks) {
+		t.Error("HasGroups: 期待: true, 取得: false")
+	}
+	if HasGroups(bookmarks[:1]) {
+		t.Error("HasGroups: 期待: false, 取得: true")
+	}
+}
+
+func TestAddToGroup(t *testing.T) {
+	testPath := filepath.Join(t.TempDir(), FileName)
+
+	if err := AddToGroup(testPath, `C:\A\X`, `/顧客A\案件X/`); err != nil {
+		t.Fatalf("追加に失敗しました: %v", err)
+	}
+	if err := Add(testPath, `C:\Home`); err != nil {
+		t.Fatalf("追加に失敗しました: %v", err)
+	}
+	// 既存のブックマークはグループを指定した場合だけ移動する
+	if err := Add(testPath, `C:\A\X`); err != nil {
+		t.Fatalf("追加に失敗しました: %v", err)
+	}
+	if err := AddToGroup(testPath, `c:\home`, "個人"); err != nil {
+		t.Fatalf("追加に失敗しました: %v", err)
+	}
+
+	bookmarks, err := Load(testPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	expected := []string{"顧客A/案件X", "個人"}
+	var got []string
+	for _, b := range bookmarks {
+		got = append(got, b.Group)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, got)
+	}
+}
+
+func TestFormat_WithGroup(t *testing.T) {
+	bookmarks := []Bookmark{
+		{Name: "x", Path: `C:\A\X`, Group: "顧客A/案件X"},
+		{Name: "home", Path: `C:\Home`},
+	}
+
+	expected := []string{
+		`顧客A/案件X/x  C:\A\X`,
+		`home           C:\Home`,
+	}
+	if got := Format(bookmarks); !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, got)
+	}
+}
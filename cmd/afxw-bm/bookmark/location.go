@@ -0,0 +1,157 @@
+package bookmark
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/tana9/afxw-tools/internal/fileutil"
+)
+
+// EnvFile はブックマークファイルのパスを指定する環境変数です。
+const EnvFile = "AFXW_BM_FILE"
+
+// locator はブックマークファイルのパスを決めるための情報です。
+type locator struct {
+	getenv      func(string) string
+	defaultPath func() (string, error)
+	legacyDir   func() (string, error)
+	rename      func(oldpath, newpath string) error
+	remove      func(name string) error
+}
+
+// Locate はブックマークファイルのパスを次の順に決めます。
+//
+//  1. flagPath（--file で指定したパス）
+//  2. 環境変数 AFXW_BM_FILE
+//  3. configured（設定ファイルの bookmark.file）
+//  4. %APPDATA%\afxw-tools\bookmarks.toml
+//
+// 4 のファイルがまだなく、実行ファイルと同じディレクトリに以前のブックマークファイルがある場合は、
+// 4 の場所へ移動してから使います。移動できない場合は以前の場所の TOML 形式のファイルをそのまま使います。
+// テキスト形式のファイルは直接読み込めないため、移動できない場合も以前の場所のパスは返しません。
+func Locate(flagPath string, configured string) (string, error) {
+	l := locator{getenv: os.Getenv, defaultPath: GetDefaultPath, legacyDir: LegacyDir, rename: os.Rename, remove: os.Remove}
+	return l.locate(flagPath, configured)
+}
+
+func (l locator) locate(flagPath string, configured string) (string, error) {
+	for _, path := range []string{flagPath, l.getenv(EnvFile), configured} {
+		if path != "" {
+			return Expand(path), nil
+		}
+	}
+
+	path, err := l.defaultPath()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	dir, err := l.legacyDir()
+	if err != nil {
+		return path, nil
+	}
+	if legacy, err := l.moveLegacy(dir, path); err != nil && !isLegacy(legacy) {
+		return legacy, nil
+	}
+	return path, nil
+}
+
+// GetDefaultPath はブックマークファイルのデフォルトパスを返します。
+// Windows では %APPDATA%\afxw-tools\bookmarks.toml になります。
+func GetDefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "afxw-tools", FileName), nil
+}
+
+// LegacyDir は以前のバージョンがブックマークファイルを置いていた、実行ファイルのディレクトリを返します。
+func LegacyDir() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(exe), nil
+}
+
+// moveLegacy は dir にある以前のブックマークファイルを path に移動します。
+// TOML 形式のファイルがあればそれを、なければテキスト形式のファイルを path と同じディレクトリへ移動します。
+// テキスト形式のファイルは次の Load で TOML 形式に移行されます。
+//
+// 以前のファイルがない場合は空文字を返します。移動できなかった場合は以前のファイルのパスとエラーを返します。
+func (l locator) moveLegacy(dir string, path string) (string, error) {
+	moves := []struct{ src, dst string }{
+		{filepath.Join(dir, FileName), path},
+		{filepath.Join(dir, LegacyFileName), legacyPath(path)},
+	}
+	for _, m := range moves {
+		if _, err := os.Stat(m.src); err != nil {
+			continue
+		}
+		if samePath(m.src, m.dst) {
+			return "", nil
+		}
+		// 複数のプロセスが同時に移動しないように、移動先のロックを取得してから移動する
+		err := fileutil.WithLock(path, func() error {
+			if _, err := os.Stat(m.dst); err == nil {
+				return nil
+			}
+			if _, err := os.Stat(m.src); errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return l.moveFile(m.src, m.dst)
+		})
+		if err != nil {
+			return m.src, fmt.Errorf("以前のブックマークファイルの移動に失敗しました: %w", err)
+		}
+		return m.src, nil
+	}
+	return "", nil
+}
+
+// samePath は a と b が同じファイルを指すかを返します。
+func samePath(a, b string) bool {
+	ia, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	ib, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(ia, ib)
+}
+
+// moveFile は src を dst に移動します。
+// 別のドライブなどで名前の変更ができない場合は、コピーしてから元のファイルを削除します。
+// コピーできれば移動は完了したものとし、読み取り専用の場所などで元のファイルを削除できなくてもエラーにしません。
+func (l locator) moveFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("ディレクトリの作成に失敗しました: %w", err)
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("移動先のファイルが既に存在します: %s", dst)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := l.rename(src, dst); err == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := fileutil.WriteAtomic(dst, data); err != nil {
+		return err
+	}
+	l.remove(src)
+	return nil
+}
@@ -0,0 +1,217 @@
+package bookmark
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testLocator は tmpDir 以下を既定の場所と以前の場所にする locator を作成します。
+func testLocator(t *testing.T, env map[string]string) (l locator, defaultPath string, legacyDir string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	defaultPath = filepath.Join(tmpDir, "appdata", "afxw-tools", FileName)
+	legacyDir = filepath.Join(tmpDir, "exe")
+	if err := os.MkdirAll(legacyDir, 0755); err != nil {
+		t.Fatalf("ディレクトリ作成に失敗しました: %v", err)
+	}
+	l = locator{
+		getenv:      func(name string) string { return env[name] },
+		defaultPath: func() (string, error) { return defaultPath, nil },
+		legacyDir:   func() (string, error) { return legacyDir, nil },
+		rename:      os.Rename,
+		remove:      os.Remove,
+	}
+	return l, defaultPath, legacyDir
+}
+
+func TestLocate_Order(t *testing.T) {
+	t.Setenv("AFXW_BM_TEST_DIR", `D:\sync`)
+
+	tests := []struct {
+		name       string
+		flagPath   string
+		env        string
+		configured string
+		expected   string
+	}{
+		{"--file を優先", `C:\flag.toml`, `C:\env.toml`, `C:\config.toml`, `C:\flag.toml`},
+		{"環境変数", "", `C:\env.toml`, `C:\config.toml`, `C:\env.toml`},
+		{"設定ファイル", "", "", `C:\config.toml`, `C:\config.toml`},
+		{"環境変数を展開", "", "", `%AFXW_BM_TEST_DIR%\bookmarks.toml`, `D:\sync\bookmarks.toml`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _, _ := testLocator(t, map[string]string{EnvFile: tt.env})
+			got, err := l.locate(tt.flagPath, tt.configured)
+			if err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLocate_Default(t *testing.T) {
+	l, defaultPath, _ := testLocator(t, nil)
+
+	got, err := l.locate("", "")
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if got != defaultPath {
+		t.Errorf("期待: %q, 取得: %q", defaultPath, got)
+	}
+}
+
+func TestLocate_MovesLegacyFile(t *testing.T) {
+	l, defaultPath, legacyDir := testLocator(t, nil)
+	legacy := filepath.Join(legacyDir, FileName)
+	saveTestBookmarks(t, legacy, `C:\Work`)
+
+	got, err := l.locate("", "")
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if got != defaultPath {
+		t.Errorf("期待: %q, 取得: %q", defaultPath, got)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("以前のファイルが残っています: %v", err)
+	}
+	bookmarks, err := Load(got)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(bookmarks) != 1 || bookmarks[0].Path != `C:\Work` {
+		t.Errorf("移動後のブックマークが正しくありません: %v", paths(bookmarks))
+	}
+
+	// 移動後は既定の場所のファイルをそのまま使う
+	if got, err := l.locate("", ""); err != nil || got != defaultPath {
+		t.Errorf("期待: %q, 取得: %q, %v", defaultPath, got, err)
+	}
+}
+
+func TestLocate_MovesLegacyTextFile(t *testing.T) {
+	l, defaultPath, legacyDir := testLocator(t, nil)
+	legacy := filepath.Join(legacyDir, LegacyFileName)
+	if err := os.WriteFile(legacy, []byte("C:\\Work\nC:\\Docs\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	got, err := l.locate("", "")
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	// テキスト形式のファイルは移動先で TOML 形式に移行される
+	bookmarks, err := Load(got)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(bookmarks) != 2 {
+		t.Errorf("期待: 2件, 取得: %v", paths(bookmarks))
+	}
+	if _, err := os.Stat(defaultPath); err != nil {
+		t.Errorf("移行後のファイルがありません: %v", err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("以前のファイルが残っています: %v", err)
+	}
+}
+
+func TestLocate_LegacyFallback(t *testing.T) {
+	l, defaultPath, legacyDir := testLocator(t, nil)
+	legacy := filepath.Join(legacyDir, FileName)
+	saveTestBookmarks(t, legacy, `C:\Work`)
+
+	// 既定の場所のディレクトリを作れない場合は以前の場所を使う
+	blocker := filepath.Dir(filepath.Dir(defaultPath))
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	got, err := l.locate("", "")
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if got != legacy {
+		t.Errorf("期待: %q, 取得: %q", legacy, got)
+	}
+}
+
+func TestLocate_LegacyTextFallback(t *testing.T) {
+	l, defaultPath, legacyDir := testLocator(t, nil)
+	legacy := filepath.Join(legacyDir, LegacyFileName)
+	if err := os.WriteFile(legacy, []byte("C:\\Work\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+	// 読み取り専用の場所などで名前の変更も削除もできない場合は、コピーできれば移動したものとする
+	l.rename = func(string, string) error { return os.ErrPermission }
+	l.remove = func(string) error { return os.ErrPermission }
+
+	got, err := l.locate("", "")
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if got != defaultPath {
+		t.Errorf("期待: %q, 取得: %q", defaultPath, got)
+	}
+	bookmarks, err := Load(got)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(bookmarks) != 1 || bookmarks[0].Path != `C:\Work` {
+		t.Errorf("移動後のブックマークが正しくありません: %v", paths(bookmarks))
+	}
+}
+
+func TestLocate_LegacyTextNotMoved(t *testing.T) {
+	l, defaultPath, legacyDir := testLocator(t, nil)
+	if err := os.WriteFile(filepath.Join(legacyDir, LegacyFileName), []byte("C:\\Work\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	// コピーもできない場合も、テキスト形式のファイルは直接読み込めないため以前の場所のパスは返さない
+	blocker := filepath.Dir(filepath.Dir(defaultPath))
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	got, err := l.locate("", "")
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if got != defaultPath {
+		t.Errorf("期待: %q, 取得: %q", defaultPath, got)
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src.toml")
+	dst := filepath.Join(tmpDir, "new", "dst.toml")
+	if err := os.WriteFile(src, []byte("data"), 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	l := locator{rename: os.Rename, remove: os.Remove}
+	if err := l.moveFile(src, dst); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if data, err := os.ReadFile(dst); err != nil || string(data) != "data" {
+		t.Errorf("移動先の内容が正しくありません: %q, %v", data, err)
+	}
+
+	// 移動先が既にある場合は上書きしない
+	if err := os.WriteFile(src, []byte("other"), 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+	if err := l.moveFile(src, dst); err == nil {
+		t.Error("エラーが期待されましたが、nilが返りました")
+	}
+}
@@ -0,0 +1,171 @@
+package bookmark
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	// helperPathEnv が設定されている場合、TestHelperProcess はブックマークを追加するヘルパーとして動作します。
+	helperPathEnv = "AFXW_BM_TEST_HELPER_PATH"
+	helperIDEnv   = "AFXW_BM_TEST_HELPER_ID"
+
+	hammerWorkers   = 8
+	hammerPerWorker = 10
+	sharedDir       = `C:\Shared`
+)
+
+// addMany は worker ごとに異なるパスと、全 worker で共通のパスを追加します。
+func addMany(path, worker string) error {
+	for i := range hammerPerWorker {
+		if err := Add(path, fmt.Sprintf(`C:\%s\Dir%d`, worker, i)); err != nil {
+			return err
+		}
+		if err := Add(path, sharedDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkHammered はすべての追加が重複なく反映されていることを確認します。
+func checkHammered(t *testing.T, path string, workers []string) {
+	t.Helper()
+
+	bookmarks, err := Load(path)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+
+	// Load は重複を取り除くため、ファイルの内容そのものも確認する
+	raw, err := read(path)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(raw) != len(bookmarks) {
+		t.Errorf("ファイルに重複があります: %d件中 %d件が一意", len(raw), len(bookmarks))
+	}
+
+	expected := len(workers)*hammerPerWorker + 1
+	if len(bookmarks) != expected {
+		t.Errorf("期待: %d個, 取得: %d個", expected, len(bookmarks))
+	}
+	for _, w := range workers {
+		for i := range hammerPerWorker {
+			dir := fmt.Sprintf(`C:\%s\Dir%d`, w, i)
+			if Index(bookmarks, dir) < 0 {
+				t.Errorf("追加したブックマークがありません: %s", dir)
+			}
+		}
+	}
+}
+
+func TestAdd_ConcurrentGoroutines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	var workers []string
+	for w := range hammerWorkers {
+		workers = append(workers, fmt.Sprintf("g%d", w))
+	}
+
+	// 書き込み中に読み込んでも壊れた内容が見えないことを確認する
+	var done atomic.Bool
+	var readErr atomic.Value
+	var readers sync.WaitGroup
+	readers.Go(func() {
+		for !done.Load() {
+			if _, err := Load(path); err != nil {
+				readErr.Store(err)
+				return
+			}
+		}
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(workers))
+	for _, w := range workers {
+		wg.Go(func() {
+			errs <- addMany(path, w)
+		})
+	}
+	wg.Wait()
+	done.Store(true)
+	readers.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("追加に失敗しました: %v", err)
+		}
+	}
+	if err := readErr.Load(); err != nil {
+		t.Fatalf("書き込み中の読み込みに失敗しました: %v", err)
+	}
+	checkHammered(t, path, workers)
+}
+
+func TestAdd_ConcurrentProcesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("-short の場合はスキップします")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("テストバイナリのパス取得に失敗しました: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), FileName)
+
+	// テストバイナリ自身を TestHelperProcess だけを実行するように複数起動する
+	var workers []string
+	var cmds []*exec.Cmd
+	for w := range hammerWorkers / 2 {
+		id := fmt.Sprintf("p%d", w)
+		workers = append(workers, id)
+		cmd := exec.Command(exe, "-test.run=^TestHelperProcess$")
+		cmd.Env = append(os.Environ(), helperPathEnv+"="+path, helperIDEnv+"="+id)
+		cmds = append(cmds, cmd)
+	}
+
+	// プロセスと並行して goroutine からも追加する
+	workers = append(workers, "g")
+	var wg sync.WaitGroup
+	var goErr error
+	wg.Go(func() {
+		goErr = addMany(path, "g")
+	})
+
+	outputs := make([][]byte, len(cmds))
+	errs := make([]error, len(cmds))
+	for i, cmd := range cmds {
+		wg.Go(func() {
+			outputs[i], errs[i] = cmd.CombinedOutput()
+		})
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("ヘルパープロセスが失敗しました: %v\n%s", err, outputs[i])
+		}
+	}
+	if goErr != nil {
+		t.Fatalf("追加に失敗しました: %v", goErr)
+	}
+	checkHammered(t, path, workers)
+}
+
+// TestHelperProcess は TestAdd_ConcurrentProcesses から別プロセスとして起動され、ブックマークを追加します。
+func TestHelperProcess(t *testing.T) {
+	path := os.Getenv(helperPathEnv)
+	if path == "" {
+		return
+	}
+	if err := addMany(path, os.Getenv(helperIDEnv)); err != nil {
+		fmt.Fprintf(os.Stderr, "追加に失敗しました: %v\n", err)
+		os.Exit(1)
+	}
+}
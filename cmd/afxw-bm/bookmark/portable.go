@@ -0,0 +1,176 @@
+package bookmark
+
+import (
+	"os"
+	"strings"
+
+	"github.com/tana9/afxw-tools/internal/winpath"
+)
+
+// portableVars は Portable でパスの先頭を置き換える環境変数です。
+// 値が最も長く一致するものを使うため、並び順は問いません。
+var portableVars = []string{
+	"USERPROFILE",
+	"OneDrive",
+	"APPDATA",
+	"LOCALAPPDATA",
+	"PUBLIC",
+	"ProgramData",
+	"ProgramFiles",
+	"ProgramFiles(x86)",
+	"SystemRoot",
+}
+
+// Expand はパスに含まれる %VAR%、$VAR、${VAR} を環境変数の値に、先頭の ~ をホームディレクトリに置き換えます。
+// 設定されていない環境変数はそのまま残します。
+func Expand(path string) string {
+	return expand(path, os.LookupEnv, os.UserHomeDir)
+}
+
+// expand は lookup と home を使ってパスを展開します。
+func expand(path string, lookup func(string) (string, bool), home func() (string, error)) string {
+	if !strings.ContainsAny(path, "%$~") {
+		return path
+	}
+
+	expanded := path
+	if rest, ok := strings.CutPrefix(expanded, "~"); ok && (rest == "" || rest[0] == '\\' || rest[0] == '/') {
+		if dir, err := home(); err == nil && dir != "" {
+			expanded = dir + rest
+		}
+	}
+	expanded = expandPercent(expanded, lookup)
+	expanded = expandDollar(expanded, lookup)
+
+	if expanded == path {
+		return path
+	}
+	return winpath.Clean(expanded)
+}
+
+// expandPercent は %VAR% を展開します。
+func expandPercent(s string, lookup func(string) (string, bool)) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(s, '%')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start+1:], '%')
+		if end < 0 {
+			break
+		}
+		end += start + 1
+
+		name := s[start+1 : end]
+		if v, ok := lookup(name); ok && name != "" {
+			b.WriteString(s[:start])
+			b.WriteString(v)
+			s = s[end+1:]
+			continue
+		}
+		// 変数として展開できない場合は閉じの % を次の変数の開始として扱う
+		b.WriteString(s[:end])
+		s = s[end:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// expandDollar は $VAR と ${VAR} を展開します。
+func expandDollar(s string, lookup func(string) (string, bool)) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 {
+			break
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+
+		name, width := dollarName(s)
+		if v, ok := lookup(name); ok && name != "" {
+			b.WriteString(v)
+		} else {
+			b.WriteString(s[:width])
+		}
+		s = s[width:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// dollarName は $ で始まる s から変数名と、$ を含めた参照全体の長さを返します。
+// 変数名にできない場合は空文字と $ の長さを返します。
+func dollarName(s string) (string, int) {
+	if strings.HasPrefix(s, "${") {
+		if end := strings.IndexByte(s, '}'); end > 2 {
+			return s[2:end], end + 1
+		}
+		return "", 1
+	}
+	n := 1
+	for n < len(s) && isNameByte(s[n], n == 1) {
+		n++
+	}
+	if n == 1 {
+		return "", 1
+	}
+	return s[1:n], n
+}
+
+// isNameByte は c が環境変数名に使える文字かを返します。
+func isNameByte(c byte, first bool) bool {
+	switch {
+	case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		return true
+	case '0' <= c && c <= '9':
+		return !first
+	}
+	return false
+}
+
+// Portable はパスの先頭が既知の環境変数の値に一致する場合、その部分を %VAR% に置き換えます。
+// 複数の環境変数に一致する場合は値が最も長いものを使います。一致しない場合はそのまま返します。
+func Portable(path string) string {
+	return portable(path, os.LookupEnv)
+}
+
+// portable は lookup を使ってパスの先頭を環境変数に置き換えます。
+func portable(path string, lookup func(string) (string, bool)) string {
+	path = winpath.Clean(path)
+
+	best, bestLen := "", 0
+	for _, name := range portableVars {
+		v, ok := lookup(name)
+		if !ok || v == "" {
+			continue
+		}
+		v = strings.TrimSuffix(winpath.Clean(v), `\`)
+		if len(v) <= bestLen || !hasPathPrefix(path, v) {
+			continue
+		}
+		best, bestLen = name, len(v)
+	}
+	if best == "" {
+		return path
+	}
+	return "%" + best + "%" + path[bestLen:]
+}
+
+// hasPathPrefix は path が prefix 自身、またはその配下を指すかを大文字小文字を区別せずに返します。
+func hasPathPrefix(path, prefix string) bool {
+	if len(path) < len(prefix) || !strings.EqualFold(path[:len(prefix)], prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || path[len(prefix)] == '\\'
+}
+
+// storedPath はブックマークファイルに書き込むパスを返します。
+// 読み込んだときのパスを展開した結果が今のパスと同じであれば、展開前の形を保ちます。
+func (b Bookmark) storedPath() string {
+	if b.stored != "" && winpath.Equal(Expand(b.stored), b.Path) {
+		return b.stored
+	}
+	return b.Path
+}
@@ -0,0 +1,173 @@
+package bookmark
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// testEnv はテスト用の環境変数の一覧から lookup 関数を作成します。
+func testEnv(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+// storedPaths はブックマークファイルに書かれているパスを展開せずに返します。
+func storedPaths(t *testing.T, path string) []string {
+	t.Helper()
+	var f file
+	if _, err := toml.DecodeFile(path, &f); err != nil {
+		t.Fatalf("ブックマークファイルの解析に失敗しました: %v", err)
+	}
+	return paths(f.Bookmarks)
+}
+
+func TestExpand(t *testing.T) {
+	env := testEnv(map[string]string{
+		"USERPROFILE": `C:\Users\me`,
+		"DATA":        `E:\data`,
+		"APPDATA":     `C:\Users\me\AppData\Roaming`,
+	})
+	home := func() (string, error) { return `C:\Users\me`, nil }
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"変数なし", `C:\Work`, `C:\Work`},
+		{"%VAR%", `%USERPROFILE%\work`, `C:\Users\me\work`},
+		{"%VAR% の大文字小文字はそのまま", `%DATA%\src`, `E:\data\src`},
+		{"$VAR", `$DATA\src`, `E:\data\src`},
+		{"${VAR}", `${DATA}src`, `E:\datasrc`},
+		{"~ のみ", `~`, `C:\Users\me`},
+		{"~ で始まるパス", `~\Documents`, `C:\Users\me\Documents`},
+		{"~/ で始まるパス", `~/Documents`, `C:\Users\me\Documents`},
+		{"途中の ~ は展開しない", `C:\PROGRA~1\app`, `C:\PROGRA~1\app`},
+		{"~user は展開しない", `~other\work`, `~other\work`},
+		{"未設定の %VAR% は残す", `%UNKNOWN%\work`, `%UNKNOWN%\work`},
+		{"未設定の $VAR は残す", `C:\$Recycle.Bin`, `C:\$Recycle.Bin`},
+		{"管理共有の $ は残す", `\\server\c$\work`, `\\server\c$\work`},
+		{"未設定の後の %VAR%", `%UNKNOWN%%DATA%\x`, `%UNKNOWN%E:\data\x`},
+		{"閉じていない %", `C:\100%\work`, `C:\100%\work`},
+		{"展開後は正規化", `%APPDATA%\..\Local`, `C:\Users\me\AppData\Local`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expand(tt.path, env, home); got != tt.expected {
+				t.Errorf("expand(%q) 期待: %q, 取得: %q", tt.path, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExpand_NoHome(t *testing.T) {
+	home := func() (string, error) { return "", errors.New("ホームディレクトリがありません") }
+	if got := expand(`~\work`, testEnv(nil), home); got != `~\work` {
+		t.Errorf("期待: %q, 取得: %q", `~\work`, got)
+	}
+}
+
+func TestPortable(t *testing.T) {
+	env := testEnv(map[string]string{
+		"USERPROFILE":  `C:\Users\me`,
+		"APPDATA":      `C:\Users\me\AppData\Roaming`,
+		"LOCALAPPDATA": `C:\Users\me\AppData\Local\`,
+		"OneDrive":     "",
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"ホームの配下", `C:\Users\me\work`, `%USERPROFILE%\work`},
+		{"ホームそのもの", `C:\Users\me`, `%USERPROFILE%`},
+		{"大文字小文字を区別しない", `c:\users\ME\work`, `%USERPROFILE%\work`},
+		{"最も長く一致する変数を使う", `C:\Users\me\AppData\Roaming\app`, `%APPDATA%\app`},
+		{"値の末尾の区切り文字は無視する", `C:\Users\me\AppData\Local\app`, `%LOCALAPPDATA%\app`},
+		{"名前の途中では置き換えない", `C:\Users\meme\work`, `C:\Users\meme\work`},
+		{"一致しない", `D:\data`, `D:\data`},
+		{"正規化してから比較する", `C:/Users/me/work/`, `%USERPROFILE%\work`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := portable(tt.path, env); got != tt.expected {
+				t.Errorf("portable(%q) 期待: %q, 取得: %q", tt.path, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoad_ExpandsVariables(t *testing.T) {
+	t.Setenv("AFXW_BM_TEST_DATA", `E:\data`)
+
+	testPath := filepath.Join(t.TempDir(), FileName)
+	content := `[[bookmark]]
+name = "src"
+path = '%AFXW_BM_TEST_DATA%\src'
+created = 2026-01-02T03:04:05Z
+
+[[bookmark]]
+name = "work"
+path = 'C:\Work'
+created = 2026-01-02T03:04:05Z
+`
+	if err := os.WriteFile(testPath, []byte(content), 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	bookmarks, err := Load(testPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if bookmarks[0].Path != `E:\data\src` {
+		t.Errorf("期待: %q, 取得: %q", `E:\data\src`, bookmarks[0].Path)
+	}
+
+	// 展開後のパスで移動を記録しても、ファイルには展開前の形で保存される
+	if err := Touch(testPath, `E:\data\src`, time.Now()); err != nil {
+		t.Fatalf("記録に失敗しました: %v", err)
+	}
+	if got := storedPaths(t, testPath)[0]; got != `%AFXW_BM_TEST_DATA%\src` {
+		t.Errorf("展開前のパスが保存されていません: %q", got)
+	}
+
+	// 展開前のパスでも指定できる
+	if i, err := Lookup(bookmarks, `%AFXW_BM_TEST_DATA%\src`); err != nil || i != 0 {
+		t.Errorf("Lookup 期待: 0, 取得: %d, %v", i, err)
+	}
+}
+
+func TestAdd_Portable(t *testing.T) {
+	t.Setenv("AFXW_BM_TEST_DATA", `E:\data`)
+	testPath := filepath.Join(t.TempDir(), FileName)
+
+	if err := Add(testPath, `%AFXW_BM_TEST_DATA%\src`); err != nil {
+		t.Fatalf("追加に失敗しました: %v", err)
+	}
+	// 同じディレクトリを展開後のパスで追加しても重複しない
+	if err := Add(testPath, `E:\data\src`); err != nil {
+		t.Fatalf("追加に失敗しました: %v", err)
+	}
+
+	bookmarks, err := Load(testPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(bookmarks) != 1 || bookmarks[0].Path != `E:\data\src` || bookmarks[0].Name != "src" {
+		t.Fatalf("期待: [E:\\data\\src], 取得: %v", bookmarks)
+	}
+
+	if got := storedPaths(t, testPath)[0]; got != `%AFXW_BM_TEST_DATA%\src` {
+		t.Errorf("展開前のパスが保存されていません: %q", got)
+	}
+}
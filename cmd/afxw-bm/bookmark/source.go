@@ -0,0 +1,63 @@
+package bookmark
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrReadOnly は読み取り専用のブックマークファイルを変更しようとしたことを表します。
+var ErrReadOnly = errors.New("読み取り専用のブックマークです")
+
+// Source はまとめて表示するブックマークファイルの1つを表します。
+type Source struct {
+	Label    string // ファインダーに表示する名前。個人用のファイルは空にします
+	Path     string // ブックマークファイルのパス
+	ReadOnly bool   // 移動の記録や削除などでファイルを変更しない
+}
+
+// Source はブックマークを読み込んだファイルを返します。
+// LoadSources 以外で読み込んだブックマークではゼロ値を返します。
+func (b Bookmark) Source() Source {
+	return b.source
+}
+
+// LoadSources は sources の順にブックマークファイルを読み込み、1つにまとめます。
+// 同じパスを指すブックマークは先に読み込んだものだけを残します。
+// 読み取り専用のファイルは LoadReadOnly で読み込み、移行などでファイルを変更しません。
+//
+// 先頭のファイル（個人用のファイル）が読み込めない場合はエラーを返します。
+// 共有フォルダのファイルなど2番目以降のファイルが読み込めない場合は、
+// そのファイルを除いてまとめ、読み込めなかった理由を skipped に返します。
+func LoadSources(sources []Source) (bookmarks []Bookmark, skipped []error, err error) {
+	for i, src := range sources {
+		load := Load
+		if src.ReadOnly {
+			load = LoadReadOnly
+		}
+		loaded, err := load(src.Path)
+		if err != nil {
+			if i == 0 {
+				return nil, nil, err
+			}
+			skipped = append(skipped, fmt.Errorf("%s (%s): %w", src.Label, src.Path, err))
+			continue
+		}
+		for _, b := range loaded {
+			b.source = src
+			bookmarks = append(bookmarks, b)
+		}
+	}
+	if bookmarks == nil {
+		bookmarks = []Bookmark{}
+	}
+	return dedup(bookmarks), skipped, nil
+}
+
+// Writable は b を読み込んだファイルを変更できるかを確認し、そのファイルのパスを返します。
+// 読み取り専用のファイルの場合は ErrReadOnly を返します。
+func Writable(b Bookmark) (string, error) {
+	if b.source.ReadOnly {
+		return "", fmt.Errorf("%w: %s（%s）", ErrReadOnly, b.Name, b.source.Label)
+	}
+	return b.source.Path, nil
+}
@@ -0,0 +1,173 @@
+package bookmark
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// saveTestBookmarks は dirs を指すブックマークファイルを作成します。
+func saveTestBookmarks(t *testing.T, path string, dirs ...string) {
+	t.Helper()
+	bookmarks := make([]Bookmark, len(dirs))
+	for i, dir := range dirs {
+		bookmarks[i] = New(dir, time.Now())
+	}
+	if err := Save(path, bookmarks); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+}
+
+func TestLoadSources(t *testing.T) {
+	tmpDir := t.TempDir()
+	personal := Source{Path: filepath.Join(tmpDir, "personal.toml")}
+	team := Source{Label: "チーム", Path: filepath.Join(tmpDir, "team.toml"), ReadOnly: true}
+	missing := Source{Label: "なし", Path: filepath.Join(tmpDir, "missing.toml")}
+
+	saveTestBookmarks(t, personal.Path, `C:\Work`, `C:\Docs`)
+	saveTestBookmarks(t, team.Path, `c:\work\`, `\\server\share\project`)
+
+	bookmarks, skipped, err := LoadSources([]Source{personal, team, missing})
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("存在しないファイルは空として扱うべきです: %v", skipped)
+	}
+
+	// 同じパスは先のファイルのものを残す
+	expected := []string{`C:\Work`, `C:\Docs`, `\\server\share\project`}
+	if got := paths(bookmarks); !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, got)
+	}
+	expectedSources := []Source{personal, personal, team}
+	for i, b := range bookmarks {
+		if b.Source() != expectedSources[i] {
+			t.Errorf("%d: 読み込み元 期待: %+v, 取得: %+v", i, expectedSources[i], b.Source())
+		}
+	}
+}
+
+func TestLoadSources_Unreadable(t *testing.T) {
+	tmpDir := t.TempDir()
+	personal := Source{Path: filepath.Join(tmpDir, "personal.toml")}
+	saveTestBookmarks(t, personal.Path, `C:\Work`)
+
+	// ディレクトリはファイルとして読み込めない
+	broken := Source{Label: "共有", Path: filepath.Join(tmpDir, "shared")}
+	if err := os.Mkdir(broken.Path, 0755); err != nil {
+		t.Fatalf("ディレクトリ作成に失敗しました: %v", err)
+	}
+
+	// 2番目以降のファイルは読み飛ばす
+	bookmarks, skipped, err := LoadSources([]Source{personal, broken})
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if len(skipped) != 1 || len(bookmarks) != 1 {
+		t.Errorf("期待: 1件を読み込み1件を読み飛ばす, 取得: %v, %v", paths(bookmarks), skipped)
+	}
+
+	// 個人用のファイルが読み込めない場合はエラー
+	if _, _, err := LoadSources([]Source{broken, personal}); err == nil {
+		t.Error("エラーが期待されましたが、nilが返りました")
+	}
+}
+
+func TestLoadSources_ReadOnlyLegacy(t *testing.T) {
+	tmpDir := t.TempDir()
+	personal := Source{Path: filepath.Join(tmpDir, "personal.toml")}
+	saveTestBookmarks(t, personal.Path, `C:\Work`)
+
+	// 共有フォルダにテキスト形式のファイルしかない読み取り専用のファイル
+	sharedDir := filepath.Join(tmpDir, "shared")
+	if err := os.Mkdir(sharedDir, 0755); err != nil {
+		t.Fatalf("ディレクトリ作成に失敗しました: %v", err)
+	}
+	legacy := filepath.Join(sharedDir, LegacyFileName)
+	if err := os.WriteFile(legacy, []byte("C:\\Team\n\\\\server\\share\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+	team := Source{Label: "チーム", Path: filepath.Join(sharedDir, FileName), ReadOnly: true}
+
+	bookmarks, skipped, err := LoadSources([]Source{personal, team})
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("読み飛ばすべきではありません: %v", skipped)
+	}
+	expected := []string{`C:\Work`, `C:\Team`, `\\server\share`}
+	if got := paths(bookmarks); !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, got)
+	}
+
+	// 移行もロックも行わず、共有フォルダのファイルはそのまま残す
+	entries, err := os.ReadDir(sharedDir)
+	if err != nil {
+		t.Fatalf("ディレクトリの読み込みに失敗しました: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != LegacyFileName {
+		t.Errorf("読み取り専用のフォルダが変更されています: %v", entries)
+	}
+}
+
+func TestLoadSources_LegacyFileAsSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	personal := Source{Path: filepath.Join(tmpDir, "personal.toml")}
+	saveTestBookmarks(t, personal.Path, `C:\Work`)
+
+	// テキスト形式のファイルを直接指定した場合は、解析のエラーを読み飛ばした理由として返す
+	legacy := Source{Label: "旧形式", Path: filepath.Join(tmpDir, LegacyFileName)}
+	if err := os.WriteFile(legacy.Path, []byte("C:\\Team\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	for _, readOnly := range []bool{false, true} {
+		legacy.ReadOnly = readOnly
+		bookmarks, skipped, err := LoadSources([]Source{personal, legacy})
+		if err != nil {
+			t.Fatalf("予期しないエラー: %v", err)
+		}
+		if len(skipped) != 1 || !strings.Contains(skipped[0].Error(), "解析に失敗しました") {
+			t.Errorf("readonly=%v: 解析のエラーが期待されましたが、%v でした", readOnly, skipped)
+		}
+		if len(bookmarks) != 1 {
+			t.Errorf("readonly=%v: 個人用のブックマークだけが期待されましたが、%q でした", readOnly, paths(bookmarks))
+		}
+	}
+	if _, err := os.Stat(legacy.Path + ".bak"); !os.IsNotExist(err) {
+		t.Error("直接指定したテキスト形式のファイルを移行するべきではありません")
+	}
+}
+
+func TestWritable(t *testing.T) {
+	b := New(`C:\Work`, time.Now())
+	b.source = Source{Path: `C:\bm.toml`}
+	if path, err := Writable(b); err != nil || path != `C:\bm.toml` {
+		t.Errorf("期待: C:\\bm.toml, 取得: %q, %v", path, err)
+	}
+
+	b.source = Source{Label: "チーム", Path: `\\server\bm.toml`, ReadOnly: true}
+	if _, err := Writable(b); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("ErrReadOnly が期待されましたが、%v が返りました", err)
+	}
+}
+
+func TestFormat_WithSource(t *testing.T) {
+	bookmarks := []Bookmark{
+		{Name: "work", Path: `C:\Work`},
+		{Name: "project", Path: `\\server\project`, Alias: "p", source: Source{Label: "チーム"}},
+	}
+	expected := []string{
+		`work     C:\Work`,
+		`project  \\server\project  [チーム] @p`,
+	}
+	if got := Format(bookmarks); !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, got)
+	}
+}
@@ -0,0 +1,158 @@
+package bookmark
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mattn/go-runewidth"
+	"github.com/tana9/afxw-tools/internal/fileutil"
+)
+
+// WorkspaceFileName はワークスペースを保存するファイルの名前です。
+// 個人用のブックマークファイルと同じディレクトリに置きます。
+const WorkspaceFileName = "workspaces.toml"
+
+// Workspace は左右の窓のディレクトリの組を表します。
+type Workspace struct {
+	Name     string    `toml:"name"`
+	Left     string    `toml:"left"`
+	Right    string    `toml:"right"`
+	Created  time.Time `toml:"created"`             // 保存した日時
+	LastUsed time.Time `toml:"last_used,omitempty"` // 最後に開いた日時
+}
+
+// workspaceFile はワークスペースファイルの形式です。
+type workspaceFile struct {
+	Workspaces []Workspace `toml:"workspace"`
+}
+
+// WorkspacePath は bmPath のブックマークファイルと同じディレクトリにあるワークスペースファイルのパスを返します。
+func WorkspacePath(bmPath string) string {
+	return filepath.Join(filepath.Dir(bmPath), WorkspaceFileName)
+}
+
+// LoadWorkspaces は指定されたファイルからワークスペースを読み込みます。
+// ファイルが存在しない場合は空のスライスを返します。
+// 左右のパスに含まれる環境変数などは展開します。
+func LoadWorkspaces(path string) ([]Workspace, error) {
+	workspaces, err := readWorkspaces(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, ws := range workspaces {
+		workspaces[i].Left = Expand(ws.Left)
+		workspaces[i].Right = Expand(ws.Right)
+	}
+	return workspaces, nil
+}
+
+// readWorkspaces はワークスペースファイルを展開せずに読み込みます。
+func readWorkspaces(path string) ([]Workspace, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Workspace{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ワークスペースファイルの読み込みに失敗しました: %w", err)
+	}
+
+	var f workspaceFile
+	if err := toml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("ワークスペースファイルの解析に失敗しました: %w", err)
+	}
+	if f.Workspaces == nil {
+		return []Workspace{}, nil
+	}
+	return f.Workspaces, nil
+}
+
+// UpdateWorkspaces はワークスペースを読み込み、fn で更新してから保存します。
+// 読み込みから保存までロックを保持します。fn がエラーを返した場合は保存しません。
+func UpdateWorkspaces(path string, fn func(workspaces []Workspace) ([]Workspace, error)) error {
+	return fileutil.WithLock(path, func() error {
+		workspaces, err := readWorkspaces(path)
+		if err != nil {
+			return err
+		}
+		workspaces, err = fn(workspaces)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(workspaceFile{Workspaces: workspaces}); err != nil {
+			return fmt.Errorf("ワークスペースのエンコードに失敗しました: %w", err)
+		}
+		return fileutil.WriteAtomic(path, buf.Bytes())
+	})
+}
+
+// IndexWorkspace は名前が name に大文字小文字を区別せずに一致するワークスペースの位置を返します。
+// 見つからない場合は -1 を返します。
+func IndexWorkspace(workspaces []Workspace, name string) int {
+	for i, ws := range workspaces {
+		if strings.EqualFold(ws.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// SaveWorkspace はワークスペースを保存します。
+// 同じ名前のワークスペースがある場合は、作成日時を残して左右のパスを置き換えます。
+func SaveWorkspace(path string, ws Workspace) error {
+	return UpdateWorkspaces(path, func(workspaces []Workspace) ([]Workspace, error) {
+		if i := IndexWorkspace(workspaces, ws.Name); i >= 0 {
+			workspaces[i].Left = ws.Left
+			workspaces[i].Right = ws.Right
+			return workspaces, nil
+		}
+		return append(workspaces, ws), nil
+	})
+}
+
+// RemoveWorkspace は名前が name に一致するワークスペースを削除します。
+func RemoveWorkspace(path string, name string) error {
+	return UpdateWorkspaces(path, func(workspaces []Workspace) ([]Workspace, error) {
+		i := IndexWorkspace(workspaces, name)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return append(workspaces[:i], workspaces[i+1:]...), nil
+	})
+}
+
+// TouchWorkspace は名前が name に一致するワークスペースを開いた日時を記録します。
+// 該当するワークスペースがない場合は何もしません。
+func TouchWorkspace(path string, name string, now time.Time) error {
+	return UpdateWorkspaces(path, func(workspaces []Workspace) ([]Workspace, error) {
+		if i := IndexWorkspace(workspaces, name); i >= 0 {
+			workspaces[i].LastUsed = now
+		}
+		return workspaces, nil
+	})
+}
+
+// FormatWorkspaces はファインダーに表示する "名前  左窓のパス | 右窓のパス" 形式の文字列を返します。
+// 名前と左窓のパスの列は表示幅を揃えます。
+func FormatWorkspaces(workspaces []Workspace) []string {
+	nameWidth, leftWidth := 0, 0
+	for _, ws := range workspaces {
+		nameWidth = max(nameWidth, runewidth.StringWidth(ws.Name))
+		leftWidth = max(leftWidth, runewidth.StringWidth(ws.Left))
+	}
+
+	lines := make([]string, len(workspaces))
+	for i, ws := range workspaces {
+		lines[i] = runewidth.FillRight(ws.Name, nameWidth) + "  " +
+			runewidth.FillRight(ws.Left, leftWidth) + " | " + ws.Right
+	}
+	return lines
+}
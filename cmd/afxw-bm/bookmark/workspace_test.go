@@ -0,0 +1,110 @@
+package bookmark
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSaveWorkspace(t *testing.T) {
+	wsPath := filepath.Join(t.TempDir(), WorkspaceFileName)
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if err := SaveWorkspace(wsPath, Workspace{Name: "deploy", Left: `C:\src`, Right: `\\server\deploy`, Created: created}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if err := SaveWorkspace(wsPath, Workspace{Name: "docs", Left: `C:\docs`, Right: `D:\out`, Created: created}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	// 同じ名前（大文字小文字は区別しない）は作成日時を残して上書きする
+	if err := SaveWorkspace(wsPath, Workspace{Name: "Deploy", Left: `C:\src2`, Right: `\\server\deploy2`, Created: time.Now()}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	workspaces, err := LoadWorkspaces(wsPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	expected := []Workspace{
+		{Name: "deploy", Left: `C:\src2`, Right: `\\server\deploy2`, Created: created},
+		{Name: "docs", Left: `C:\docs`, Right: `D:\out`, Created: created},
+	}
+	if !reflect.DeepEqual(workspaces, expected) {
+		t.Errorf("期待: %+v, 取得: %+v", expected, workspaces)
+	}
+}
+
+func TestLoadWorkspaces_Expand(t *testing.T) {
+	t.Setenv("AFXW_BM_TEST_SHARE", `\\server\share`)
+	wsPath := filepath.Join(t.TempDir(), WorkspaceFileName)
+
+	if err := SaveWorkspace(wsPath, Workspace{Name: "share", Left: `%AFXW_BM_TEST_SHARE%\src`, Right: `C:\out`}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	workspaces, err := LoadWorkspaces(wsPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if workspaces[0].Left != `\\server\share\src` {
+		t.Errorf("期待: \\\\server\\share\\src, 取得: %s", workspaces[0].Left)
+	}
+
+	// 保存されている形式は変えない
+	stored, err := readWorkspaces(wsPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if stored[0].Left != `%AFXW_BM_TEST_SHARE%\src` {
+		t.Errorf("期待: %%AFXW_BM_TEST_SHARE%%\\src, 取得: %s", stored[0].Left)
+	}
+}
+
+func TestLoadWorkspaces_NotExist(t *testing.T) {
+	workspaces, err := LoadWorkspaces(filepath.Join(t.TempDir(), WorkspaceFileName))
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if len(workspaces) != 0 {
+		t.Errorf("空のスライスが期待されましたが、%+v が返りました", workspaces)
+	}
+}
+
+func TestRemoveWorkspace(t *testing.T) {
+	wsPath := filepath.Join(t.TempDir(), WorkspaceFileName)
+	for _, name := range []string{"a", "b"} {
+		if err := SaveWorkspace(wsPath, Workspace{Name: name, Left: `C:\` + name, Right: `D:\` + name}); err != nil {
+			t.Fatalf("予期しないエラー: %v", err)
+		}
+	}
+
+	if err := RemoveWorkspace(wsPath, "A"); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if err := RemoveWorkspace(wsPath, "x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ErrNotFound が期待されましたが、%v が返りました", err)
+	}
+
+	workspaces, err := LoadWorkspaces(wsPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(workspaces) != 1 || workspaces[0].Name != "b" {
+		t.Errorf("期待: [b], 取得: %+v", workspaces)
+	}
+}
+
+func TestFormatWorkspaces(t *testing.T) {
+	workspaces := []Workspace{
+		{Name: "deploy", Left: `C:\src`, Right: `\\server\deploy`},
+		{Name: "資料", Left: `C:\Users\Test\docs`, Right: `D:\out`},
+	}
+	expected := []string{
+		`deploy  C:\src             | \\server\deploy`,
+		`資料    C:\Users\Test\docs | D:\out`,
+	}
+	if got := FormatWorkspaces(workspaces); !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, got)
+	}
+}
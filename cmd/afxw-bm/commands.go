@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/finder"
+	"github.com/urfave/cli/v3"
+)
+
+// commands はブックマーク管理用のサブコマンドを返します。
+func commands() []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:    "list",
+			Aliases: []string{"ls"},
+			Usage:   "ブックマークを一覧表示",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				sources, err := bookmarkSources(cmd)
+				if err != nil {
+					return err
+				}
+				return runList(os.Stdout, sources)
+			},
+		},
+		{
+			Name:      "rm",
+			Usage:     "ブックマークを削除（省略時はファインダーで選択）",
+			ArgsUsage: "[名前またはパス...]",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				sources, err := bookmarkSources(cmd)
+				if err != nil {
+					return err
+				}
+				return runRemove(os.Stdout, &finder.TUIFinder{}, sources, cmd.Args().Slice())
+			},
+		},
+		{
+			Name:      "mv",
+			Usage:     "ブックマークの名前を変更",
+			ArgsUsage: "<名前またはパス> <新しい名前>",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				sources, err := bookmarkSources(cmd)
+				if err != nil {
+					return err
+				}
+				return runRename(os.Stdout, sources, cmd.Args().Slice())
+			},
+		},
+		{
+			Name:      "go",
+			Usage:     "エイリアスに一致するブックマークへファインダーを開かずに移動",
+			ArgsUsage: "<エイリアス>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:    "opposite",
+					Aliases: []string{"o"},
+					Usage:   "反対窓で開く",
+				},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				if cmd.Args().Len() != 1 {
+					return errors.New("エイリアスを1つ指定してください")
+				}
+				sources, err := bookmarkSources(cmd)
+				if err != nil {
+					return err
+				}
+				return withAFX(ctx, func(a afx.AFX) error {
+					return runGo(ctx, a, &finder.TUIFinder{}, sources, cmd.Args().First(), cmd.Bool("opposite"))
+				})
+			},
+		},
+		{
+			Name:      "alias",
+			Usage:     "ブックマークのエイリアスを設定（エイリアス省略時は削除）",
+			ArgsUsage: "<名前またはパス> [エイリアス]",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				sources, err := bookmarkSources(cmd)
+				if err != nil {
+					return err
+				}
+				return runAlias(os.Stdout, sources, cmd.Args().Slice())
+			},
+		},
+		{
+			Name:  "edit",
+			Usage: "ブックマークファイルをエディタ（環境変数 EDITOR、未設定時はメモ帳）で開く",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				bmPath, err := bookmarkPath(cmd)
+				if err != nil {
+					return err
+				}
+				return runEdit(ctx, bmPath)
+			},
+		},
+		{
+			Name:  "prune",
+			Usage: "存在しないディレクトリやファイルのブックマークを削除",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:    "dry-run",
+					Aliases: []string{"n"},
+					Usage:   "削除せずに対象を表示するだけにする",
+				},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				sources, err := bookmarkSources(cmd)
+				if err != nil {
+					return err
+				}
+				return runPrune(os.Stdout, sources, pathExists, cmd.Bool("dry-run"))
+			},
+		},
+		{
+			Name:  "doctor",
+			Usage: "ブックマークのパスを確認し、移動したフォルダの移動先をファインダーで選んで修正",
+			Flags: []cli.Flag{
+				&cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "1件のブックマークの確認を待つ時間（応答のない共有フォルダで止まらないようにする）",
+					Value: defaultCheckTimeout,
+				},
+				&cli.BoolFlag{
+					Name:    "dry-run",
+					Aliases: []string{"n"},
+					Usage:   "修正せずに確認結果と候補を表示するだけにする",
+				},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				sources, err := bookmarkSources(cmd)
+				if err != nil {
+					return err
+				}
+				return runDoctor(ctx, os.Stdout, &finder.TUIFinder{}, sources, doctorOptions{
+					timeout: cmd.Duration("timeout"),
+					dryRun:  cmd.Bool("dry-run"),
+				})
+			},
+		},
+		workspaceCommand(),
+	}
+}
+
+// runList はすべてのブックマークファイルのブックマークを "名前  パス  [読み込み元] #タグ" の形式で一覧表示します。
+func runList(w io.Writer, sources []bookmark.Source) error {
+	bookmarks, err := loadBookmarks(sources)
+	if err != nil {
+		return err
+	}
+	for _, line := range bookmark.Format(bookmarks) {
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
+
+// runRemove は keys に一致するブックマークを削除します。
+// keys が空の場合はファインダーで削除するブックマークを選択します。
+// 読み取り専用のファイルのブックマークは削除できません。
+func runRemove(w io.Writer, f finder.Finder, sources []bookmark.Source, keys []string) error {
+	if len(keys) == 0 {
+		bookmarks, err := loadBookmarks(sources)
+		if err != nil {
+			return err
+		}
+		if len(bookmarks) == 0 {
+			fmt.Fprintln(w, "ブックマークが見つかりません。")
+			return nil
+		}
+
+		idx, err := f.Find(bookmark.Format(bookmarks))
+		if err != nil {
+			// ESCやCtrl+Cでキャンセルされた場合は正常終了
+			if errors.Is(err, fuzzyfinder.ErrAbort) {
+				return nil
+			}
+			return err
+		}
+		keys = []string{bookmarks[idx].Path}
+	}
+
+	for _, key := range keys {
+		b, bmPath, err := findWritable(sources, key)
+		if err != nil {
+			return fmt.Errorf("ブックマークの削除に失敗しました: %w", err)
+		}
+		removed, err := bookmark.Remove(bmPath, b.Path)
+		if err != nil {
+			return fmt.Errorf("ブックマークの削除に失敗しました: %w", err)
+		}
+		fmt.Fprintf(w, "ブックマークを削除しました: %s  %s\n", removed.Name, removed.Path)
+	}
+	return nil
+}
+
+// runRename は args[0] に一致するブックマークの名前を args[1] に変更します。
+func runRename(w io.Writer, sources []bookmark.Source, args []string) error {
+	if len(args) != 2 {
+		return errors.New("名前またはパスと新しい名前を指定してください")
+	}
+	b, bmPath, err := findWritable(sources, args[0])
+	if err != nil {
+		return fmt.Errorf("ブックマークの名前の変更に失敗しました: %w", err)
+	}
+	if err := bookmark.Rename(bmPath, b.Path, args[1]); err != nil {
+		return fmt.Errorf("ブックマークの名前の変更に失敗しました: %w", err)
+	}
+	fmt.Fprintf(w, "ブックマークの名前を変更しました: %s -> %s\n", args[0], args[1])
+	return nil
+}
+
+// runEdit はブックマークファイルをエディタで開き、編集後の内容を検証します。
+func runEdit(ctx context.Context, bmPath string) error {
+	// 移行前のファイルや存在しないファイルを開かないように、先に読み込んで保存しておく
+	if err := bookmark.Update(bmPath, func(bookmarks []bookmark.Bookmark) ([]bookmark.Bookmark, error) {
+		return bookmarks, nil
+	}); err != nil {
+		return fmt.Errorf("ブックマークファイルの準備に失敗しました: %w", err)
+	}
+
+	c := editorCommand(ctx, bmPath)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("エディタの実行に失敗しました: %w", err)
+	}
+
+	if _, err := bookmark.Load(bmPath); err != nil {
+		return fmt.Errorf("編集後のブックマークファイルに誤りがあります: %w", err)
+	}
+	return nil
+}
+
+// editorCommand は path を開くエディタのコマンドを返します。
+// 環境変数 EDITOR（"code --wait" のような引数付きも可）、未設定の場合はメモ帳を使います。
+func editorCommand(ctx context.Context, path string) *exec.Cmd {
+	args := strings.Fields(os.Getenv("EDITOR"))
+	if len(args) == 0 {
+		args = []string{"notepad.exe"}
+	}
+	return exec.CommandContext(ctx, args[0], append(args[1:], path)...)
+}
+
+// runPrune は exists が false を返すパスのブックマークを削除します。
+// 読み取り専用のファイルは対象にしません。
+func runPrune(w io.Writer, sources []bookmark.Source, exists func(dir string) bool, dryRun bool) error {
+	var removed []bookmark.Bookmark
+	for _, src := range sources {
+		if src.ReadOnly {
+			continue
+		}
+		r, err := bookmark.Prune(src.Path, exists, dryRun)
+		if err != nil {
+			return fmt.Errorf("ブックマークの整理に失敗しました: %w", err)
+		}
+		removed = append(removed, r...)
+	}
+	if len(removed) == 0 {
+		fmt.Fprintln(w, "存在しないパスのブックマークはありません。")
+		return nil
+	}
+
+	msg := "ブックマークを削除しました"
+	if dryRun {
+		msg = "削除対象のブックマーク"
+	}
+	for _, b := range removed {
+		fmt.Fprintf(w, "%s: %s  %s\n", msg, b.Name, b.Path)
+	}
+	return nil
+}
+
+// pathExists は path のディレクトリまたはファイルが存在するかを返します。
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+)
+
+// loadPaths はブックマークファイルに残っているパスの一覧を返します。
+func loadPaths(t *testing.T, path string) []string {
+	t.Helper()
+	bookmarks, err := bookmark.Load(path)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	paths := make([]string, len(bookmarks))
+	for i, b := range bookmarks {
+		paths[i] = b.Path
+	}
+	return paths
+}
+
+func TestRunList(t *testing.T) {
+	bmPath := filepath.Join(t.TempDir(), bookmark.FileName)
+	bookmarks := []bookmark.Bookmark{
+		{Name: "work", Path: `C:\Work`, Tags: []string{"daily"}},
+		{Name: "docs", Path: `C:\Users\Test\Documents`},
+	}
+	if err := bookmark.Save(bmPath, bookmarks); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runList(&out, personal(bmPath)); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	expected := "work  C:\\Work  #daily\ndocs  C:\\Users\\Test\\Documents\n"
+	if out.String() != expected {
+		t.Errorf("期待: %q, 取得: %q", expected, out.String())
+	}
+}
+
+func TestRunRemove(t *testing.T) {
+	dirs := []string{`C:\Users\Test\Dir1`, `C:\Users\Test\Dir2`, `C:\Users\Test\Dir3`}
+
+	tests := []struct {
+		name          string
+		keys          []string
+		finder        *afxtest.MockFinder
+		expectedPaths []string
+		expectErr     bool
+	}{
+		{
+			name:          "名前とパスで指定",
+			keys:          []string{"Dir1", `c:\users\test\dir3\`},
+			finder:        &afxtest.MockFinder{},
+			expectedPaths: []string{`C:\Users\Test\Dir2`},
+		},
+		{
+			name:          "ファインダーで選択",
+			finder:        &afxtest.MockFinder{Idx: 1},
+			expectedPaths: []string{`C:\Users\Test\Dir1`, `C:\Users\Test\Dir3`},
+		},
+		{
+			name:          "ファインダーでキャンセル",
+			finder:        &afxtest.MockFinder{Err: fuzzyfinder.ErrAbort},
+			expectedPaths: dirs,
+		},
+		{
+			name:          "存在しないブックマーク",
+			keys:          []string{"none"},
+			finder:        &afxtest.MockFinder{},
+			expectedPaths: dirs,
+			expectErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bmPath := filepath.Join(t.TempDir(), bookmark.FileName)
+			writeBookmarks(t, bmPath, dirs...)
+
+			err := runRemove(&bytes.Buffer{}, tt.finder, personal(bmPath), tt.keys)
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if got := loadPaths(t, bmPath); !reflect.DeepEqual(got, tt.expectedPaths) {
+				t.Errorf("期待: %q, 取得: %q", tt.expectedPaths, got)
+			}
+		})
+	}
+}
+
+func TestRunRename(t *testing.T) {
+	bmPath := filepath.Join(t.TempDir(), bookmark.FileName)
+	writeBookmarks(t, bmPath, `C:\Users\Test\Dir1`)
+
+	if err := runRename(&bytes.Buffer{}, personal(bmPath), []string{"Dir1"}); err == nil {
+		t.Error("引数が足りない場合はエラーが期待されましたが、nilが返りました")
+	}
+	if err := runRename(&bytes.Buffer{}, personal(bmPath), []string{"Dir1", "作業"}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	bookmarks, err := bookmark.Load(bmPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if bookmarks[0].Name != "作業" {
+		t.Errorf("期待: 作業, 取得: %s", bookmarks[0].Name)
+	}
+}
+
+func TestRunPrune(t *testing.T) {
+	tmpDir := t.TempDir()
+	bmPath := filepath.Join(tmpDir, bookmark.FileName)
+	existing := filepath.Join(tmpDir, "exists")
+	if err := os.Mkdir(existing, 0755); err != nil {
+		t.Fatalf("ディレクトリ作成に失敗しました: %v", err)
+	}
+	gone := filepath.Join(tmpDir, "gone")
+	writeBookmarks(t, bmPath, existing, gone)
+
+	var out bytes.Buffer
+	if err := runPrune(&out, personal(bmPath), pathExists, true); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if !strings.Contains(out.String(), gone) {
+		t.Errorf("削除対象が表示されていません: %q", out.String())
+	}
+	if got := loadPaths(t, bmPath); len(got) != 2 {
+		t.Errorf("dry-run で変更されています: %q", got)
+	}
+
+	if err := runPrune(&bytes.Buffer{}, personal(bmPath), pathExists, false); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if got := loadPaths(t, bmPath); !reflect.DeepEqual(got, []string{existing}) {
+		t.Errorf("期待: %q, 取得: %q", []string{existing}, got)
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	tests := []struct {
+		editor       string
+		expectedArgs []string
+	}{
+		{"", []string{"notepad.exe", `C:\bm.toml`}},
+		{"vim", []string{"vim", `C:\bm.toml`}},
+		{"code --wait", []string{"code", "--wait", `C:\bm.toml`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.editor, func(t *testing.T) {
+			t.Setenv("EDITOR", tt.editor)
+			c := editorCommand(t.Context(), `C:\bm.toml`)
+			if !reflect.DeepEqual(c.Args, tt.expectedArgs) {
+				t.Errorf("期待: %q, 取得: %q", tt.expectedArgs, c.Args)
+			}
+		})
+	}
+}
+
+func TestRunEdit_InvalidResult(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh がないためスキップします")
+	}
+
+	// エディタが不正な内容を書き込んだ場合はエラーにする
+	bmPath := filepath.Join(t.TempDir(), bookmark.FileName)
+	script := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '[[bookmark]' > \"$1\"\n"), 0755); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+	t.Setenv("EDITOR", script)
+
+	err := runEdit(t.Context(), bmPath)
+	if err == nil || !strings.Contains(err.Error(), "編集後のブックマークファイルに誤りがあります") {
+		t.Errorf("編集後の検証エラーが期待されましたが、%v が返りました", err)
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
+	"github.com/tana9/afxw-tools/internal/finder"
+)
+
+const (
+	// defaultCheckTimeout は1件のブックマークの確認を待つ既定の時間です。
+	defaultCheckTimeout = 3 * time.Second
+	// maxConcurrentChecks は同時に確認するブックマークの数です。
+	maxConcurrentChecks = 8
+	// searchTimeout は1件のブックマークの移動先の候補を探す最大時間です。
+	searchTimeout = 5 * time.Second
+	// searchDepth は移動先の候補を探すディレクトリの深さです。
+	searchDepth = 3
+	// maxCandidates は1件のブックマークについて表示する移動先の候補の最大数です。
+	maxCandidates = 10
+)
+
+// pathState はブックマークのパスの状態です。
+type pathState int
+
+const (
+	stateOK          pathState = iota
+	stateMissing               // パスが存在しない
+	stateUnreachable           // 時間内に応答がない、またはアクセスできない
+)
+
+// checkResult は1件のブックマークの確認結果です。
+type checkResult struct {
+	bookmark   bookmark.Bookmark
+	state      pathState
+	err        error
+	candidates []string // パスが存在しない場合の移動先の候補
+}
+
+// readDir はディレクトリの内容を読み込みます。テストで差し替えます。
+var readDir = os.ReadDir
+
+// doctorOptions は doctor コマンドの指定です。
+type doctorOptions struct {
+	timeout time.Duration           // 1件のブックマークの確認を待つ時間
+	dryRun  bool                    // 修正せずに結果を表示するだけにする
+	stat    func(path string) error // パスの存在を確認する（nil の場合は os.Stat）
+}
+
+// statPath は path が存在するかを確認します。
+func statPath(path string) error {
+	_, err := os.Stat(path)
+	return err
+}
+
+// runDoctor はすべてのブックマークのパスを確認し、存在しないものには移動先の候補を表示します。
+// dryRun でなければ、書き込み可能なファイルのブックマークについてファインダーで移動先を選んで修正できます。
+// 応答のない共有フォルダなどは一時的なものの場合があるため、表示するだけで修正の対象にはしません。
+func runDoctor(ctx context.Context, w io.Writer, f finder.Finder, sources []bookmark.Source, opts doctorOptions) error {
+	bookmarks, err := loadBookmarks(sources)
+	if err != nil {
+		return err
+	}
+	if len(bookmarks) == 0 {
+		fmt.Fprintln(w, "ブックマークが見つかりません。")
+		return nil
+	}
+
+	stat := opts.stat
+	if stat == nil {
+		stat = statPath
+	}
+	timeout := opts.timeout
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+
+	var problems []checkResult
+	for _, r := range checkBookmarks(ctx, bookmarks, stat, timeout) {
+		if r.state == stateOK {
+			continue
+		}
+		b := r.bookmark
+		switch r.state {
+		case stateMissing:
+			fmt.Fprintf(w, "見つかりません: %s  %s%s\n", b.Name, b.Path, readOnlyNote(b))
+			for _, c := range r.candidates {
+				fmt.Fprintf(w, "  候補: %s\n", c)
+			}
+		case stateUnreachable:
+			fmt.Fprintf(w, "応答がありません: %s  %s（%v）\n", b.Name, b.Path, r.err)
+		}
+		problems = append(problems, r)
+	}
+	if len(problems) == 0 {
+		fmt.Fprintf(w, "%d件のブックマークはすべて正常です。\n", len(bookmarks))
+		return nil
+	}
+	if opts.dryRun {
+		return nil
+	}
+
+	for _, p := range problems {
+		if p.state != stateMissing || p.bookmark.Source().ReadOnly {
+			continue
+		}
+		done, err := fixBookmark(w, f, p.bookmark, p.candidates)
+		if err != nil {
+			return err
+		}
+		if !done {
+			return nil
+		}
+	}
+	return nil
+}
+
+// readOnlyNote は読み取り専用のファイルのブックマークであれば、その旨の注記を返します。
+func readOnlyNote(b bookmark.Bookmark) string {
+	if b.Source().ReadOnly {
+		return fmt.Sprintf("  [%s]（読み取り専用のため修正できません）", b.Source().Label)
+	}
+	return ""
+}
+
+// fixBookmark はファインダーで選んだ移動先にブックマークのパスを変更するか、ブックマークを削除します。
+// ファインダーがキャンセルされた場合は false を返します。
+func fixBookmark(w io.Writer, f finder.Finder, b bookmark.Bookmark, candidates []string) (bool, error) {
+	const (
+		removeItem = "（このブックマークを削除）"
+		keepItem   = "（そのままにする）"
+	)
+	items := append(append([]string{}, candidates...), removeItem, keepItem)
+	idx, err := finder.FindWithOptions(f, items, finder.Options{
+		Header: fmt.Sprintf("%s（%s）の移動先を選択", b.Name, b.Path),
+	})
+	if err != nil {
+		// ESCやCtrl+Cでキャンセルされた場合は残りの修正をやめる
+		if errors.Is(err, fuzzyfinder.ErrAbort) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	bmPath, err := bookmark.Writable(b)
+	if err != nil {
+		return false, err
+	}
+	switch items[idx] {
+	case keepItem:
+	case removeItem:
+		if _, err := bookmark.Remove(bmPath, b.Path); err != nil {
+			return false, fmt.Errorf("ブックマークの削除に失敗しました: %w", err)
+		}
+		fmt.Fprintf(w, "ブックマークを削除しました: %s  %s\n", b.Name, b.Path)
+	default:
+		if err := bookmark.Relocate(bmPath, b.Path, items[idx]); err != nil {
+			return false, fmt.Errorf("ブックマークの移動に失敗しました: %w", err)
+		}
+		fmt.Fprintf(w, "ブックマークを移動しました: %s  %s -> %s\n", b.Name, b.Path, items[idx])
+	}
+	return true, nil
+}
+
+// checkBookmarks はブックマークのパスを並行して確認し、bookmarks と同じ順に結果を返します。
+// パスが存在しないブックマークは、同じく並行して移動先の候補を探します。
+func checkBookmarks(ctx context.Context, bookmarks []bookmark.Bookmark, stat func(string) error, timeout time.Duration) []checkResult {
+	results := make([]checkResult, len(bookmarks))
+	sem := make(chan struct{}, maxConcurrentChecks)
+	var wg sync.WaitGroup
+	for i, b := range bookmarks {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			state, err := checkPath(ctx, b.Path, stat, timeout)
+			results[i] = checkResult{bookmark: b, state: state, err: err}
+			if state == stateMissing {
+				results[i].candidates = findCandidates(ctx, b.Path, b.File)
+			}
+		})
+	}
+	wg.Wait()
+	return results
+}
+
+// checkPath は path の状態を確認します。timeout 以内に応答がない場合は stateUnreachable を返します。
+// 応答のないネットワークパスの確認は中断できないため、待つのをやめるだけで確認自体は裏で続きます。
+func checkPath(ctx context.Context, path string, stat func(string) error, timeout time.Duration) (pathState, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- stat(path) }()
+
+	select {
+	case err := <-done:
+		switch {
+		case err == nil:
+			return stateOK, nil
+		case errors.Is(err, fs.ErrNotExist):
+			return stateMissing, err
+		default:
+			return stateUnreachable, err
+		}
+	case <-ctx.Done():
+		return stateUnreachable, fmt.Errorf("%v 以内に応答がありません", timeout)
+	}
+}
+
+// wait は fn を別の goroutine で実行して結果を待ちます。fn が戻る前に ctx が終了した場合は ctx のエラーを返します。
+// checkPath と同じく、応答のないネットワークパスへのアクセスは待つのをやめるだけでアクセス自体は裏で続きます。
+func wait[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	type result struct {
+		v   T
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := fn()
+		done <- result{v, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// findCandidates は存在しなくなった path の移動先の候補を返します。
+// path の親をたどって最初に存在するディレクトリと、さらにその親の配下から、
+// 同じ名前（大文字小文字を区別しない）のディレクトリ（isFile の場合はファイル）を近い順に探します。
+// searchTimeout を過ぎた場合は、それまでに見つかった候補を返します。
+func findCandidates(ctx context.Context, path string, isFile bool) []string {
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+
+	anchor := existingAncestor(ctx, filepath.Dir(path))
+	if anchor == "" {
+		return nil
+	}
+	roots := []string{anchor}
+	if parent := filepath.Dir(anchor); parent != anchor {
+		roots = append(roots, parent)
+	}
+
+	leaf := filepath.Base(path)
+	var found []string
+	seen := make(map[string]struct{})
+	for _, root := range roots {
+		for _, c := range searchLeaf(ctx, root, leaf, isFile, maxCandidates) {
+			k := strings.ToLower(c)
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			seen[k] = struct{}{}
+			found = append(found, c)
+			if len(found) >= maxCandidates {
+				return found
+			}
+		}
+	}
+	return found
+}
+
+// existingAncestor は dir 自身またはその親のうち、最初に存在するディレクトリを返します。
+// ドライブのルートまでたどっても見つからない場合や、ctx が終了した場合は空文字を返します。
+func existingAncestor(ctx context.Context, dir string) string {
+	for {
+		info, err := wait(ctx, func() (os.FileInfo, error) { return os.Stat(dir) })
+		if err == nil && info.IsDir() {
+			return dir
+		}
+		if ctx.Err() != nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// searchLeaf は root の配下を searchDepth の深さまで幅優先で探し、名前が leaf のエントリを最大 limit 件返します。
+// "." や "$" で始まる隠しフォルダ・システムフォルダの中は探しません。
+func searchLeaf(ctx context.Context, root string, leaf string, isFile bool, limit int) []string {
+	type entry struct {
+		dir   string
+		depth int
+	}
+	read := readDir
+	var found []string
+	queue := []entry{{root, 1}}
+	for len(queue) > 0 && len(found) < limit {
+		if ctx.Err() != nil {
+			break
+		}
+		e := queue[0]
+		queue = queue[1:]
+
+		entries, err := wait(ctx, func() ([]os.DirEntry, error) { return read(e.dir) })
+		if err != nil {
+			continue
+		}
+		for _, de := range entries {
+			name := de.Name()
+			full := filepath.Join(e.dir, name)
+			if strings.EqualFold(name, leaf) && de.IsDir() != isFile {
+				found = append(found, full)
+				if len(found) >= limit {
+					break
+				}
+			}
+			if de.IsDir() && e.depth < searchDepth && !strings.HasPrefix(name, ".") && !strings.HasPrefix(name, "$") {
+				queue = append(queue, entry{full, e.depth + 1})
+			}
+		}
+	}
+	return found
+}
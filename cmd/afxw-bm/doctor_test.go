@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+)
+
+// mkdirs は root の配下にディレクトリを作成します。
+func mkdirs(t *testing.T, root string, dirs ...string) {
+	t.Helper()
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("ディレクトリの作成に失敗しました: %v", err)
+		}
+	}
+}
+
+func TestCheckBookmarks(t *testing.T) {
+	block := make(chan struct{})
+	t.Cleanup(func() { close(block) })
+
+	stat := func(path string) error {
+		switch path {
+		case `C:\Exists`:
+			return nil
+		case `C:\Gone`:
+			return fs.ErrNotExist
+		case `\\offline\share`:
+			<-block
+			return nil
+		default:
+			return errors.New("アクセスが拒否されました")
+		}
+	}
+	bookmarks := []bookmark.Bookmark{
+		bookmark.New(`C:\Exists`, time.Now()),
+		bookmark.New(`C:\Gone`, time.Now()),
+		bookmark.New(`\\offline\share`, time.Now()),
+		bookmark.New(`C:\Denied`, time.Now()),
+	}
+
+	start := time.Now()
+	results := checkBookmarks(t.Context(), bookmarks, stat, 50*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("応答のないパスで待ち続けています: %v", elapsed)
+	}
+
+	expected := []pathState{stateOK, stateMissing, stateUnreachable, stateUnreachable}
+	for i, r := range results {
+		if r.bookmark.Path != bookmarks[i].Path {
+			t.Errorf("%d: 順序が変わっています: %s", i, r.bookmark.Path)
+		}
+		if r.state != expected[i] {
+			t.Errorf("%s: 期待: %d, 取得: %d", r.bookmark.Path, expected[i], r.state)
+		}
+	}
+}
+
+func TestFindCandidates(t *testing.T) {
+	root := t.TempDir()
+	mkdirs(t, root,
+		filepath.Join("projects", "new", "App"),
+		filepath.Join("projects", "new", ".git", "app"),
+		filepath.Join("archive", "app"),
+	)
+	if err := os.WriteFile(filepath.Join(root, "projects", "new", "report.xlsx"), nil, 0644); err != nil {
+		t.Fatalf("ファイルの作成に失敗しました: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		isFile   bool
+		expected []string
+	}{
+		{
+			name:   "兄弟と親の配下から探す",
+			path:   filepath.Join(root, "projects", "old", "app"),
+			isFile: false,
+			expected: []string{
+				filepath.Join(root, "projects", "new", "App"),
+				filepath.Join(root, "archive", "app"),
+			},
+		},
+		{
+			name:     "ファイルはファイルだけを探す",
+			path:     filepath.Join(root, "projects", "old", "report.xlsx"),
+			isFile:   true,
+			expected: []string{filepath.Join(root, "projects", "new", "report.xlsx")},
+		},
+		{
+			name:     "見つからない",
+			path:     filepath.Join(root, "projects", "old", "none"),
+			isFile:   false,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findCandidates(t.Context(), tt.path, tt.isFile)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSearchLeaf_Unresponsive(t *testing.T) {
+	root := t.TempDir()
+	mkdirs(t, root, "app", filepath.Join("share", "app"))
+
+	// 応答のない共有フォルダのように、share の読み込みは戻らない
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	orig := readDir
+	readDir = func(dir string) ([]os.DirEntry, error) {
+		if filepath.Base(dir) == "share" {
+			<-release
+		}
+		return orig(dir)
+	}
+	t.Cleanup(func() { readDir = orig })
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	got := searchLeaf(ctx, root, "app", false, maxCandidates)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("応答のないディレクトリを待ち続けています: %v", elapsed)
+	}
+	// それまでに見つかった候補は返す
+	expected := []string{filepath.Join(root, "app")}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, got)
+	}
+}
+
+func TestRunDoctor(t *testing.T) {
+	tests := []struct {
+		name          string
+		finder        *afxtest.MockFinder
+		dryRun        bool
+		expectedPaths func(root string) []string
+		expectedOut   string
+	}{
+		{
+			name:   "候補へ移動する",
+			finder: &afxtest.MockFinder{Idx: 0},
+			expectedPaths: func(root string) []string {
+				return []string{filepath.Join(root, "work"), filepath.Join(root, "projects", "new", "app")}
+			},
+			expectedOut: "ブックマークを移動しました",
+		},
+		{
+			name:   "削除する",
+			finder: &afxtest.MockFinder{Idx: 1},
+			expectedPaths: func(root string) []string {
+				return []string{filepath.Join(root, "work")}
+			},
+			expectedOut: "ブックマークを削除しました",
+		},
+		{
+			name:   "そのままにする",
+			finder: &afxtest.MockFinder{Idx: 2},
+			expectedPaths: func(root string) []string {
+				return []string{filepath.Join(root, "work"), filepath.Join(root, "projects", "old", "app")}
+			},
+		},
+		{
+			name:   "キャンセル",
+			finder: &afxtest.MockFinder{Err: fuzzyfinder.ErrAbort},
+			expectedPaths: func(root string) []string {
+				return []string{filepath.Join(root, "work"), filepath.Join(root, "projects", "old", "app")}
+			},
+		},
+		{
+			name:   "dry-run では修正しない",
+			finder: &afxtest.MockFinder{},
+			dryRun: true,
+			expectedPaths: func(root string) []string {
+				return []string{filepath.Join(root, "work"), filepath.Join(root, "projects", "old", "app")}
+			},
+			expectedOut: "候補: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			mkdirs(t, root, "work", filepath.Join("projects", "new", "app"))
+			bmPath := filepath.Join(t.TempDir(), bookmark.FileName)
+			writeBookmarks(t, bmPath, filepath.Join(root, "work"), filepath.Join(root, "projects", "old", "app"))
+
+			var out bytes.Buffer
+			if err := runDoctor(t.Context(), &out, tt.finder, personal(bmPath), doctorOptions{dryRun: tt.dryRun}); err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+
+			if got := loadPaths(t, bmPath); !reflect.DeepEqual(got, tt.expectedPaths(root)) {
+				t.Errorf("期待: %q, 取得: %q", tt.expectedPaths(root), got)
+			}
+			if !strings.Contains(out.String(), tt.expectedOut) {
+				t.Errorf("出力に %q が含まれていません: %s", tt.expectedOut, out.String())
+			}
+			if tt.dryRun && tt.finder.Calls != nil {
+				t.Error("dry-run ではファインダーを開かないべきです")
+			}
+		})
+	}
+}
+
+func TestRunDoctor_Healthy(t *testing.T) {
+	root := t.TempDir()
+	bmPath := filepath.Join(t.TempDir(), bookmark.FileName)
+	writeBookmarks(t, bmPath, root)
+
+	var out bytes.Buffer
+	finderMock := &afxtest.MockFinder{}
+	if err := runDoctor(t.Context(), &out, finderMock, personal(bmPath), doctorOptions{}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if !strings.Contains(out.String(), "すべて正常です") {
+		t.Errorf("予期しない出力: %s", out.String())
+	}
+	if finderMock.Calls != nil {
+		t.Error("問題がない場合はファインダーを開かないべきです")
+	}
+}
+
+func TestRunDoctor_ReadOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	sources := []bookmark.Source{
+		{Path: filepath.Join(tmpDir, "personal.toml")},
+		{Label: "チーム", Path: filepath.Join(tmpDir, "team.toml"), ReadOnly: true},
+	}
+	writeBookmarks(t, sources[0].Path, tmpDir)
+	writeBookmarks(t, sources[1].Path, filepath.Join(tmpDir, "gone"))
+
+	var out bytes.Buffer
+	finderMock := &afxtest.MockFinder{}
+	if err := runDoctor(t.Context(), &out, finderMock, sources, doctorOptions{}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if !strings.Contains(out.String(), "読み取り専用") {
+		t.Errorf("読み取り専用であることが表示されていません: %s", out.String())
+	}
+	if finderMock.Calls != nil {
+		t.Error("読み取り専用のファイルのブックマークは修正の対象にしないべきです")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+	"github.com/tana9/afxw-tools/internal/fakeafxw"
+)
+
+func TestRunSelect_E2E(t *testing.T) {
+	tmpDir := t.TempDir()
+	bmPath := filepath.Join(tmpDir, bookmark.FileName)
+
+	writeBookmarks(t, bmPath, `C:\Users\Test\Dir1`, `C:\Users\Test\$Dir2`)
+
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	s.SetActive(afx.WindowRight)
+	a := afxtest.DialFake(t, s)
+
+	if err := runSelect(t.Context(), a, &afxtest.MockFinder{Idx: 1}, personal(bmPath), selectOptions{}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	if got := s.Path(afx.WindowRight); got != `C:\Users\Test\$Dir2` {
+		t.Errorf("期待: C:\\Users\\Test\\$Dir2, 取得: %s", got)
+	}
+}
+
+func TestRunSelect_FileBookmark_E2E(t *testing.T) {
+	bmPath := filepath.Join(t.TempDir(), bookmark.FileName)
+	bookmarks := []bookmark.Bookmark{
+		{Name: "report.xlsx", Path: `C:\Users\Test\資料\report.xlsx`, File: true},
+	}
+	if err := bookmark.Save(bmPath, bookmarks); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	a := afxtest.DialFake(t, s)
+	f := &afxtest.MockFinder{Idx: 0}
+
+	if err := runSelect(t.Context(), a, f, personal(bmPath), selectOptions{}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	// ファイルのブックマークは区別して表示し、親ディレクトリへ移動してカーソルを合わせる
+	if f.Items[0] != `* report.xlsx  C:\Users\Test\資料\report.xlsx` {
+		t.Errorf("ファイルのブックマークの表示が正しくありません: %q", f.Items[0])
+	}
+	if got := s.Path(afx.WindowLeft); got != `C:\Users\Test\資料` {
+		t.Errorf("期待: C:\\Users\\Test\\資料, 取得: %s", got)
+	}
+	if got := s.Cursor(); got != "report.xlsx" {
+		t.Errorf("カーソル 期待: report.xlsx, 取得: %q", got)
+	}
+}
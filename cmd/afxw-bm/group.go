@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
+	"github.com/tana9/afxw-tools/internal/finder"
+)
+
+// parentItem は1つ上のグループに戻る候補です。
+const parentItem = "../"
+
+// selectGrouped はグループをたどってブックマークを選択し、選択したブックマークの位置を返します。
+// グループを選ぶとその中へ移動し、"../" を選ぶか検索語が空の状態で Backspace を押すと1つ上のグループに戻ります。
+func selectGrouped(f finder.Finder, bookmarks []bookmark.Bookmark) (int, error) {
+	group := ""
+	for {
+		groups := bookmark.SubGroups(bookmarks, group)
+		indices := bookmark.InGroup(bookmarks, group)
+
+		// 候補は "../"（最上位以外）、子のグループ、グループ直下のブックマークの順に並べる
+		var items []string
+		if group != "" {
+			items = append(items, parentItem)
+		}
+		for _, g := range groups {
+			items = append(items, g+bookmark.GroupSeparator)
+		}
+		level := make([]bookmark.Bookmark, len(indices))
+		for i, idx := range indices {
+			level[i] = bookmarks[idx]
+			level[i].Group = "" // グループは見出しに表示するため名前には含めない
+		}
+		items = append(items, bookmark.Format(level)...)
+
+		idx, err := finder.FindWithOptions(f, items, finder.Options{Header: groupHeader(group), Back: true})
+		if errors.Is(err, finder.ErrBack) {
+			group = bookmark.ParentGroup(group)
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if group != "" {
+			if idx == 0 {
+				group = bookmark.ParentGroup(group)
+				continue
+			}
+			idx--
+		}
+		if idx < len(groups) {
+			group = bookmark.JoinGroup(group, groups[idx])
+			continue
+		}
+		return indices[idx-len(groups)], nil
+	}
+}
+
+// groupHeader はファインダーの見出しに表示する現在のグループを返します。
+func groupHeader(group string) string {
+	if group == "" {
+		return "ブックマーク"
+	}
+	return "ブックマーク > " + strings.ReplaceAll(group, bookmark.GroupSeparator, " > ")
+}
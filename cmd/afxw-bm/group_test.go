@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+	"github.com/tana9/afxw-tools/internal/finder"
+)
+
+func groupedBookmarks() []bookmark.Bookmark {
+	return []bookmark.Bookmark{
+		{Name: "home", Path: `C:\Home`},
+		{Name: "x", Path: `C:\A\X`, Group: "顧客A/案件X"},
+		{Name: "a", Path: `C:\A`, Group: "顧客A"},
+		{Name: "b", Path: `C:\B`, Group: "顧客B"},
+	}
+}
+
+func TestSelectGrouped(t *testing.T) {
+	tests := []struct {
+		name          string
+		steps         []afxtest.FinderStep
+		expected      int
+		expectedCalls [][]string
+		expectErr     error
+	}{
+		{
+			name:     "最上位のブックマーク",
+			steps:    []afxtest.FinderStep{{Idx: 2}},
+			expected: 0,
+			expectedCalls: [][]string{
+				{"顧客A/", "顧客B/", `home  C:\Home`},
+			},
+		},
+		{
+			name:     "グループをたどって選択",
+			steps:    []afxtest.FinderStep{{Idx: 0}, {Idx: 1}, {Idx: 1}},
+			expected: 1,
+			expectedCalls: [][]string{
+				{"顧客A/", "顧客B/", `home  C:\Home`},
+				{"../", "案件X/", `a  C:\A`},
+				{"../", `x  C:\A\X`},
+			},
+		},
+		{
+			name:     "../ で上のグループに戻る",
+			steps:    []afxtest.FinderStep{{Idx: 0}, {Idx: 0}, {Idx: 1}, {Idx: 1}},
+			expected: 3,
+			expectedCalls: [][]string{
+				{"顧客A/", "顧客B/", `home  C:\Home`},
+				{"../", "案件X/", `a  C:\A`},
+				{"顧客A/", "顧客B/", `home  C:\Home`},
+				{"../", `b  C:\B`},
+			},
+		},
+		{
+			name:     "Backspace で上のグループに戻る",
+			steps:    []afxtest.FinderStep{{Idx: 0}, {Idx: 1}, {Err: finder.ErrBack}, {Idx: 2}},
+			expected: 2,
+			expectedCalls: [][]string{
+				{"顧客A/", "顧客B/", `home  C:\Home`},
+				{"../", "案件X/", `a  C:\A`},
+				{"../", `x  C:\A\X`},
+				{"../", "案件X/", `a  C:\A`},
+			},
+		},
+		{
+			name:      "キャンセル",
+			steps:     []afxtest.FinderStep{{Idx: 0}, {Err: fuzzyfinder.ErrAbort}},
+			expectErr: fuzzyfinder.ErrAbort,
+		},
+		{
+			name:      "最上位での Backspace は最上位を表示し直す",
+			steps:     []afxtest.FinderStep{{Err: finder.ErrBack}},
+			expectErr: fuzzyfinder.ErrAbort, // 2回目の呼び出しで MockFinder がキャンセルを返す
+			expectedCalls: [][]string{
+				{"顧客A/", "顧客B/", `home  C:\Home`},
+				{"顧客A/", "顧客B/", `home  C:\Home`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &afxtest.MockFinder{Steps: tt.steps}
+			got, err := selectGrouped(f, groupedBookmarks())
+			if !errors.Is(err, tt.expectErr) {
+				t.Fatalf("エラー 期待: %v, 取得: %v", tt.expectErr, err)
+			}
+			if tt.expectErr == nil && got != tt.expected {
+				t.Errorf("期待: %d, 取得: %d", tt.expected, got)
+			}
+			if tt.expectedCalls != nil && !reflect.DeepEqual(f.Calls, tt.expectedCalls) {
+				t.Errorf("候補 期待: %q, 取得: %q", tt.expectedCalls, f.Calls)
+			}
+			if !f.Opts.Back {
+				t.Error("グループをたどる場合は Backspace で上の階層に戻れるようにするべきです")
+			}
+		})
+	}
+}
+
+func TestGroupHeader(t *testing.T) {
+	tests := []struct {
+		group    string
+		expected string
+	}{
+		{"", "ブックマーク"},
+		{"顧客A", "ブックマーク > 顧客A"},
+		{"顧客A/案件X", "ブックマーク > 顧客A > 案件X"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.group, func(t *testing.T) {
+			if got := groupHeader(tt.group); got != tt.expected {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRunSelect_Grouped(t *testing.T) {
+	bmPath := filepath.Join(t.TempDir(), bookmark.FileName)
+	if err := bookmark.Save(bmPath, groupedBookmarks()); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     selectOptions
+		steps    []afxtest.FinderStep
+		expected string
+	}{
+		{"グループをたどる", selectOptions{}, []afxtest.FinderStep{{Idx: 1}, {Idx: 1}}, `C:\B`},
+		{"--flat ではすべてから選択", selectOptions{flat: true}, []afxtest.FinderStep{{Idx: 1}}, `C:\A\X`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			afxMock := &afxtest.MockAFX{}
+			f := &afxtest.MockFinder{Steps: tt.steps}
+			if err := runSelect(t.Context(), afxMock, f, personal(bmPath), tt.opts); err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if afxMock.ExcdPath != tt.expected {
+				t.Errorf("期待: %s, 取得: %s", tt.expected, afxMock.ExcdPath)
+			}
+		})
+	}
+}
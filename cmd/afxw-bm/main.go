@@ -6,12 +6,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/ktr0731/go-fuzzyfinder"
 	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
 	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/config"
 	"github.com/tana9/afxw-tools/internal/finder"
+	"github.com/tana9/afxw-tools/internal/history"
 	"github.com/tana9/afxw-tools/internal/singleinstance"
+	"github.com/tana9/afxw-tools/internal/winpath"
+	"github.com/tana9/afxw-tools/internal/zoxide"
 	"github.com/urfave/cli/v3"
 )
 
@@ -19,37 +24,66 @@ var version = "dev"
 
 func main() {
 	cmd := &cli.Command{
-		Name:    "afxw-bm",
-		Usage:   "あふw用ブックマーク管理ツール",
-		Version: version,
+		Name:     "afxw-bm",
+		Usage:    "あふw用ブックマーク管理ツール",
+		Version:  version,
+		Commands: commands(),
 		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "file",
+				Aliases: []string{"f"},
+				Usage:   "個人用のブックマークファイル（省略時は環境変数 AFXW_BM_FILE、設定ファイル、%APPDATA%\\afxw-tools\\bookmarks.toml の順）",
+			},
 			&cli.StringFlag{
 				Name:    "add",
 				Aliases: []string{"a"},
-				Usage:   "指定されたパス（省略時はカレントディレクトリまたはあふwのアクティブパス）をブックマークに追加",
+				Usage:   "指定されたディレクトリまたはファイル（省略時はあふwのマークされたディレクトリ、アクティブパス、カレントディレクトリの順）をブックマークに追加",
 				Value:   "",
 			},
+			&cli.StringFlag{
+				Name:    "group",
+				Aliases: []string{"g"},
+				Usage:   "-a と併用し、追加するブックマークのグループ（\"顧客A/案件X\" のように / で階層を区切る）",
+			},
+			&cli.BoolFlag{
+				Name:  "portable",
+				Usage: "-a または ws save と併用し、%USERPROFILE% などの環境変数で表せるパスは環境変数を使って保存",
+			},
+			&cli.BoolFlag{
+				Name:    "opposite",
+				Aliases: []string{"o"},
+				Usage:   "選択したディレクトリを反対窓で開く",
+			},
+			&cli.BoolFlag{
+				Name:  "flat",
+				Usage: "グループをたどらずにすべてのブックマークから選択",
+			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			// -a フラグが指定されている場合
 			if cmd.IsSet("add") {
+				bmPath, err := bookmarkPath(cmd)
+				if err != nil {
+					return err
+				}
+
 				target := cmd.String("add")
+				opts := addOptions{
+					group:    cmd.String("group"),
+					portable: cmd.Bool("portable"),
+				}
 
 				// パスが指定されていない場合、あふwから取得を試みる
 				if target == "" || target == "." {
-					if a, err := afx.NewOleAFX(); err == nil {
+					if a, err := afx.New(ctx); err == nil {
 						defer a.Close()
-						if path, err := a.GetActivePath(); err == nil && path != "" {
-							target = path
-						}
-					}
-					// まだ空の場合（例：あふwが起動していない）、カレントディレクトリを使用
-					if target == "" {
-						target = "."
+						return addBookmarks(bmPath, resolveAddTargets(ctx, a), opts)
 					}
+					// あふwが起動していない場合はカレントディレクトリを使用
+					target = "."
 				}
 
-				return addBookmark(target)
+				return addBookmarks(bmPath, []string{target}, opts)
 			}
 
 			// デフォルト動作: ブックマーク選択
@@ -57,60 +91,131 @@ func main() {
 				return err
 			}
 
-			bmPath, err := bookmark.GetDefaultPath()
+			sources, err := bookmarkSources(cmd)
 			if err != nil {
-				return fmt.Errorf("ブックマークファイルのパス取得に失敗しました: %w", err)
+				return err
 			}
 
-			a, err := afx.NewOleAFX()
+			a, err := afx.New(ctx)
 			if err != nil {
 				return fmt.Errorf("afxw.obj への接続に失敗しました: %w", err)
 			}
 			defer a.Close()
+			a = zoxide.Wrap(history.Wrap(a))
 
-			return runSelect(a, &finder.GoFuzzyFinder{}, bmPath)
+			opts := selectOptions{
+				opposite: cmd.Bool("opposite"),
+				flat:     cmd.Bool("flat"),
+			}
+			return runSelect(ctx, a, &finder.TUIFinder{}, sources, opts)
 		},
 	}
 
 	if err := cmd.Run(context.Background(), os.Args); err != nil {
 		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		if errors.Is(err, afx.ErrTimeout) {
+			fmt.Fprintln(os.Stderr, "あふwがダイアログ表示中などで応答できない可能性があります。")
+		}
 		fmt.Fprintln(os.Stderr, "何かキーを押すと終了します...")
 		fmt.Scanln()
 		os.Exit(1)
 	}
 }
 
-func addBookmark(path string) error {
-	absPath, err := filepath.Abs(path)
+// bookmarkPath は個人用のブックマークファイルのパスを返します。
+func bookmarkPath(cmd *cli.Command) (string, error) {
+	cfg, err := config.LoadDefault()
 	if err != nil {
-		return fmt.Errorf("絶対パスの解決に失敗しました: %w", err)
+		return "", err
 	}
+	return locateBookmarks(cmd, cfg)
+}
 
-	bmPath, err := bookmark.GetDefaultPath()
+// locateBookmarks は --file、環境変数、設定ファイルから個人用のブックマークファイルのパスを決めます。
+func locateBookmarks(cmd *cli.Command, cfg *config.Config) (string, error) {
+	path, err := bookmark.Locate(cmd.String("file"), cfg.Bookmark.File)
 	if err != nil {
-		return fmt.Errorf("ブックマークファイルのパス取得に失敗しました: %w", err)
+		return "", fmt.Errorf("ブックマークファイルのパス取得に失敗しました: %w", err)
+	}
+	return path, nil
+}
+
+// resolveAddTargets はあふwから追加対象のパスを取得します。
+// マークされたディレクトリがあればそれらを、なければアクティブパスを返します。
+// どちらも取得できない場合はカレントディレクトリを返します。
+func resolveAddTargets(ctx context.Context, a afx.AFX) []string {
+	if dirs, err := afx.TargetDirs(ctx, a); err == nil && len(dirs) > 0 {
+		return dirs
+	}
+	return []string{"."}
+}
+
+// addOptions はブックマークの追加方法を指定します。
+type addOptions struct {
+	group    string // 追加先のグループ
+	portable bool   // 環境変数で表せるパスは環境変数を使って保存する
+}
+
+// addBookmarks は複数のパスを bmPath のブックマークファイルに追加します。
+func addBookmarks(bmPath string, paths []string, opts addOptions) error {
+	for _, path := range paths {
+		if err := addBookmark(bmPath, path, opts); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	if err := bookmark.Add(bmPath, absPath); err != nil {
+func addBookmark(bmPath string, path string, opts addOptions) error {
+	path = bookmark.Expand(path)
+	absPath := path
+	if !winpath.IsAbs(path) {
+		p, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("絶対パスの解決に失敗しました: %w", err)
+		}
+		absPath = p
+	}
+
+	if opts.portable {
+		absPath = bookmark.Portable(absPath)
+	}
+
+	if err := bookmark.AddToGroup(bmPath, absPath, opts.group); err != nil {
 		return fmt.Errorf("ブックマークの追加に失敗しました: %w", err)
 	}
 
-	fmt.Printf("ブックマークに追加しました: %s\n", absPath)
+	if opts.group != "" {
+		fmt.Printf("ブックマークに追加しました: %s（グループ: %s）\n", absPath, bookmark.CleanGroup(opts.group))
+	} else {
+		fmt.Printf("ブックマークに追加しました: %s\n", absPath)
+	}
 	return nil
 }
 
-func runSelect(a afx.AFX, f finder.Finder, bmPath string) error {
-	dirs, err := bookmark.Load(bmPath)
+// selectOptions はブックマーク選択の動作を指定します。
+type selectOptions struct {
+	opposite bool // 反対窓を移動する
+	flat     bool // グループをたどらずにすべてのブックマークから選択する
+}
+
+func runSelect(ctx context.Context, a afx.AFX, f finder.Finder, sources []bookmark.Source, opts selectOptions) error {
+	bookmarks, err := loadBookmarks(sources)
 	if err != nil {
-		return fmt.Errorf("ブックマークの読み込みに失敗しました: %w", err)
+		return err
 	}
 
-	if len(dirs) == 0 {
+	if len(bookmarks) == 0 {
 		fmt.Println("ブックマークが見つかりません。'afxw-bm -a' でブックマークを追加してください。")
 		return nil
 	}
 
-	idx, err := f.Find(dirs)
+	var idx int
+	if bookmark.HasGroups(bookmarks) && !opts.flat {
+		idx, err = selectGrouped(f, bookmarks)
+	} else {
+		idx, err = f.Find(bookmark.Format(bookmarks))
+	}
 	if err != nil {
 		// ESCやCtrl+Cでキャンセルされた場合は正常終了
 		if errors.Is(err, fuzzyfinder.ErrAbort) {
@@ -119,9 +224,28 @@ func runSelect(a afx.AFX, f finder.Finder, bmPath string) error {
 		return err
 	}
 
-	if err := a.EXCD(dirs[idx]); err != nil {
-		return fmt.Errorf("ディレクトリ移動に失敗しました: %w", err)
+	return jumpTo(ctx, a, bookmarks[idx], opts.opposite)
+}
+
+// jumpTo はブックマークのディレクトリへ移動し、ブックマークの使用履歴を記録します。
+// ファイルのブックマークはファイルがあるディレクトリへ移動し、カーソルをそのファイルに合わせます。
+// 読み取り専用のファイルのブックマークは使用履歴を記録しません。
+func jumpTo(ctx context.Context, a afx.AFX, b bookmark.Bookmark, opposite bool) error {
+	if bmPath, err := bookmark.Writable(b); err == nil && bmPath != "" {
+		a = afx.WithJumpHook(a, func(ctx context.Context, dir string) error {
+			if err := bookmark.Touch(bmPath, b.Path, time.Now()); err != nil {
+				return fmt.Errorf("ブックマークの使用履歴の記録に失敗しました: %w", err)
+			}
+			return nil
+		})
 	}
 
+	jump := afx.Jump
+	if b.File {
+		jump = afx.JumpToFile
+	}
+	if err := jump(ctx, a, b.Path, opposite); err != nil {
+		return fmt.Errorf("ディレクトリ移動に失敗しました: %w", err)
+	}
 	return nil
 }
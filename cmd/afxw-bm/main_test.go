@@ -4,25 +4,43 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
+	"github.com/tana9/afxw-tools/internal/afx"
 	"github.com/tana9/afxw-tools/internal/afxtest"
 )
 
+// writeBookmarks は dirs を指すブックマークファイルを作成します。
+func writeBookmarks(t *testing.T, path string, dirs ...string) {
+	t.Helper()
+	bookmarks := make([]bookmark.Bookmark, len(dirs))
+	for i, dir := range dirs {
+		bookmarks[i] = bookmark.New(dir, time.Now())
+	}
+	if err := bookmark.Save(path, bookmarks); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+}
+
+// personal は path だけを個人用のファイルとして読み込むブックマークファイルの一覧を返します。
+func personal(path string) []bookmark.Source {
+	return []bookmark.Source{{Path: path}}
+}
+
 func TestRunSelect_Normal(t *testing.T) {
 	tmpDir := t.TempDir()
-	bmPath := filepath.Join(tmpDir, "bookmarks.txt")
+	bmPath := filepath.Join(tmpDir, bookmark.FileName)
 
-	content := "C:\\Users\\Test\\Dir1\nC:\\Users\\Test\\Dir2\n"
-	if err := os.WriteFile(bmPath, []byte(content), 0644); err != nil {
-		t.Fatalf("テストファイル作成に失敗しました: %v", err)
-	}
+	writeBookmarks(t, bmPath, `C:\Users\Test\Dir1`, `C:\Users\Test\Dir2`)
 
 	afxMock := &afxtest.MockAFX{}
 	finderMock := &afxtest.MockFinder{Idx: 1}
 
-	if err := runSelect(afxMock, finderMock, bmPath); err != nil {
+	if err := runSelect(t.Context(), afxMock, finderMock, personal(bmPath), selectOptions{}); err != nil {
 		t.Fatalf("予期しないエラー: %v", err)
 	}
 
@@ -33,13 +51,13 @@ func TestRunSelect_Normal(t *testing.T) {
 
 func TestRunSelect_EmptyBookmarks(t *testing.T) {
 	tmpDir := t.TempDir()
-	bmPath := filepath.Join(tmpDir, "bookmarks.txt")
+	bmPath := filepath.Join(tmpDir, bookmark.FileName)
 
 	afxMock := &afxtest.MockAFX{}
 	finderMock := &afxtest.MockFinder{}
 
 	// ファイルなし（空のブックマーク）
-	if err := runSelect(afxMock, finderMock, bmPath); err != nil {
+	if err := runSelect(t.Context(), afxMock, finderMock, personal(bmPath), selectOptions{}); err != nil {
 		t.Fatalf("予期しないエラー: %v", err)
 	}
 
@@ -51,49 +69,43 @@ func TestRunSelect_EmptyBookmarks(t *testing.T) {
 
 func TestRunSelect_FinderCancelled(t *testing.T) {
 	tmpDir := t.TempDir()
-	bmPath := filepath.Join(tmpDir, "bookmarks.txt")
+	bmPath := filepath.Join(tmpDir, bookmark.FileName)
 
-	if err := os.WriteFile(bmPath, []byte("C:\\Users\\Test\\Dir1\n"), 0644); err != nil {
-		t.Fatalf("テストファイル作成に失敗しました: %v", err)
-	}
+	writeBookmarks(t, bmPath, `C:\Users\Test\Dir1`)
 
 	afxMock := &afxtest.MockAFX{}
 	finderMock := &afxtest.MockFinder{Err: fuzzyfinder.ErrAbort}
 
 	// キャンセルは正常終了
-	if err := runSelect(afxMock, finderMock, bmPath); err != nil {
+	if err := runSelect(t.Context(), afxMock, finderMock, personal(bmPath), selectOptions{}); err != nil {
 		t.Fatalf("キャンセルはエラーになるべきではありません: %v", err)
 	}
 }
 
 func TestRunSelect_FinderError(t *testing.T) {
 	tmpDir := t.TempDir()
-	bmPath := filepath.Join(tmpDir, "bookmarks.txt")
+	bmPath := filepath.Join(tmpDir, bookmark.FileName)
 
-	if err := os.WriteFile(bmPath, []byte("C:\\Users\\Test\\Dir1\n"), 0644); err != nil {
-		t.Fatalf("テストファイル作成に失敗しました: %v", err)
-	}
+	writeBookmarks(t, bmPath, `C:\Users\Test\Dir1`)
 
 	afxMock := &afxtest.MockAFX{}
 	finderMock := &afxtest.MockFinder{Err: errors.New("finder error")}
 
-	if err := runSelect(afxMock, finderMock, bmPath); err == nil {
+	if err := runSelect(t.Context(), afxMock, finderMock, personal(bmPath), selectOptions{}); err == nil {
 		t.Error("エラーが期待されましたが、nilが返りました")
 	}
 }
 
 func TestRunSelect_ExcdError(t *testing.T) {
 	tmpDir := t.TempDir()
-	bmPath := filepath.Join(tmpDir, "bookmarks.txt")
+	bmPath := filepath.Join(tmpDir, bookmark.FileName)
 
-	if err := os.WriteFile(bmPath, []byte("C:\\Users\\Test\\Dir1\n"), 0644); err != nil {
-		t.Fatalf("テストファイル作成に失敗しました: %v", err)
-	}
+	writeBookmarks(t, bmPath, `C:\Users\Test\Dir1`)
 
 	afxMock := &afxtest.MockAFX{ExcdErr: errors.New("excd error")}
 	finderMock := &afxtest.MockFinder{Idx: 0}
 
-	err := runSelect(afxMock, finderMock, bmPath)
+	err := runSelect(t.Context(), afxMock, finderMock, personal(bmPath), selectOptions{})
 	if err == nil {
 		t.Error("エラーが期待されましたが、nilが返りました")
 	}
@@ -101,3 +113,108 @@ func TestRunSelect_ExcdError(t *testing.T) {
 		t.Errorf("予期しないエラーメッセージ: %v", err)
 	}
 }
+
+func TestRunSelect_Opposite(t *testing.T) {
+	tmpDir := t.TempDir()
+	bmPath := filepath.Join(tmpDir, bookmark.FileName)
+
+	writeBookmarks(t, bmPath, `C:\Users\Test\Dir1`)
+
+	afxMock := &afxtest.MockAFX{}
+	finderMock := &afxtest.MockFinder{Idx: 0}
+
+	if err := runSelect(t.Context(), afxMock, finderMock, personal(bmPath), selectOptions{opposite: true}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	if afxMock.ExcdOppositePath != `C:\Users\Test\Dir1` {
+		t.Errorf("期待: C:\\Users\\Test\\Dir1, 取得: %s", afxMock.ExcdOppositePath)
+	}
+	if afxMock.ExcdPath != "" {
+		t.Errorf("アクティブ窓は変更されるべきではありません: %s", afxMock.ExcdPath)
+	}
+}
+
+func TestRunSelect_RecordsUse(t *testing.T) {
+	tmpDir := t.TempDir()
+	bmPath := filepath.Join(tmpDir, bookmark.FileName)
+
+	bookmarks := []bookmark.Bookmark{
+		{Name: "one", Path: `C:\Users\Test\Dir1`, Tags: []string{"work"}},
+		{Name: "two", Path: `C:\Users\Test\Dir2`},
+	}
+	if err := bookmark.Save(bmPath, bookmarks); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	afxMock := &afxtest.MockAFX{}
+	finderMock := &afxtest.MockFinder{Idx: 0}
+
+	if err := runSelect(t.Context(), afxMock, finderMock, personal(bmPath), selectOptions{}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	// ファインダーには "名前  パス  #タグ" の形式で表示する
+	expectedItems := []string{`one  C:\Users\Test\Dir1  #work`, `two  C:\Users\Test\Dir2`}
+	if !reflect.DeepEqual(finderMock.Items, expectedItems) {
+		t.Errorf("期待: %q, 取得: %q", expectedItems, finderMock.Items)
+	}
+
+	got, err := bookmark.Load(bmPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if got[0].UseCount != 1 || got[0].LastUsed.IsZero() {
+		t.Errorf("使用履歴が記録されていません: %+v", got[0])
+	}
+	if got[1].UseCount != 0 {
+		t.Errorf("選択していないブックマークは変更されるべきではありません: %+v", got[1])
+	}
+}
+
+func TestResolveAddTargets(t *testing.T) {
+	tmpDir := t.TempDir()
+	dir1 := filepath.Join(tmpDir, "dir1")
+	dir2 := filepath.Join(tmpDir, "dir2")
+	for _, d := range []string{dir1, dir2} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatalf("ディレクトリ作成に失敗しました: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		afxMock  *afxtest.MockAFX
+		expected []string
+	}{
+		{
+			name: "マークされたディレクトリをすべて追加",
+			afxMock: &afxtest.MockAFX{
+				MarkedFilesResult: []string{dir1, dir2},
+				ExtractResults:    map[string]string{afx.MacroActivePath: tmpDir},
+			},
+			expected: []string{dir1, dir2},
+		},
+		{
+			name: "マークがなければアクティブパス",
+			afxMock: &afxtest.MockAFX{
+				ExtractResults: map[string]string{afx.MacroActivePath: tmpDir},
+			},
+			expected: []string{tmpDir},
+		},
+		{
+			name:     "取得できなければカレントディレクトリ",
+			afxMock:  &afxtest.MockAFX{MarkedFilesErr: errors.New("marked error")},
+			expected: []string{"."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolveAddTargets(t.Context(), tt.afxMock)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
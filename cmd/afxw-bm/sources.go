@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
+	"github.com/tana9/afxw-tools/internal/config"
+	"github.com/tana9/afxw-tools/internal/winpath"
+	"github.com/urfave/cli/v3"
+)
+
+// bookmarkSources は読み込むブックマークファイルの一覧を返します。
+// 先頭が個人用のファイルで、続けて設定ファイルの [[bookmark.source]] に記述したファイルが並びます。
+func bookmarkSources(cmd *cli.Command) ([]bookmark.Source, error) {
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		return nil, err
+	}
+	personal, err := locateBookmarks(cmd, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return sourcesFromConfig(personal, cfg.Bookmark.Sources), nil
+}
+
+// sourcesFromConfig は個人用のファイルと設定ファイルに記述されたファイルから読み込むファイルの一覧を作成します。
+// 同じファイルが複数回記述されている場合は最初のものだけを使います。
+func sourcesFromConfig(personal string, configured []config.BookmarkSource) []bookmark.Source {
+	sources := []bookmark.Source{{Path: personal}}
+	for _, c := range configured {
+		path := bookmark.Expand(strings.TrimSpace(c.Path))
+		if path == "" || containsSource(sources, path) {
+			continue
+		}
+		label := c.Label
+		if label == "" {
+			base := winpath.Base(path)
+			label = strings.TrimSuffix(base, filepath.Ext(base))
+		}
+		sources = append(sources, bookmark.Source{Label: label, Path: path, ReadOnly: c.ReadOnly})
+	}
+	return sources
+}
+
+// containsSource は sources に path のファイルが含まれているかを返します。
+func containsSource(sources []bookmark.Source, path string) bool {
+	for _, s := range sources {
+		if winpath.Equal(s.Path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadBookmarks はすべてのブックマークファイルを読み込んでまとめます。
+// 共有フォルダに接続できないなどで読み込めなかったファイルは警告を表示して読み飛ばします。
+func loadBookmarks(sources []bookmark.Source) ([]bookmark.Bookmark, error) {
+	bookmarks, skipped, err := bookmark.LoadSources(sources)
+	if err != nil {
+		return nil, fmt.Errorf("ブックマークの読み込みに失敗しました: %w", err)
+	}
+	for _, err := range skipped {
+		fmt.Fprintf(os.Stderr, "警告: ブックマークファイルを読み込めませんでした: %v\n", err)
+	}
+	return bookmarks, nil
+}
+
+// findWritable は key に一致するブックマークと、そのブックマークを変更するファイルのパスを返します。
+// 読み取り専用のファイルのブックマークの場合は bookmark.ErrReadOnly を返します。
+func findWritable(sources []bookmark.Source, key string) (bookmark.Bookmark, string, error) {
+	bookmarks, err := loadBookmarks(sources)
+	if err != nil {
+		return bookmark.Bookmark{}, "", err
+	}
+	i, err := bookmark.Lookup(bookmarks, key)
+	if err != nil {
+		return bookmark.Bookmark{}, "", err
+	}
+	path, err := bookmark.Writable(bookmarks[i])
+	if err != nil {
+		return bookmark.Bookmark{}, "", err
+	}
+	return bookmarks[i], path, nil
+}
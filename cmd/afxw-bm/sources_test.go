@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+	"github.com/tana9/afxw-tools/internal/config"
+)
+
+// layeredSources は個人用、読み取り専用のチーム用、書き込み可能な共有用のブックマークファイルを作成します。
+func layeredSources(t *testing.T) []bookmark.Source {
+	t.Helper()
+	tmpDir := t.TempDir()
+	sources := []bookmark.Source{
+		{Path: filepath.Join(tmpDir, "personal.toml")},
+		{Label: "チーム", Path: filepath.Join(tmpDir, "team.toml"), ReadOnly: true},
+		{Label: "共有", Path: filepath.Join(tmpDir, "shared.toml")},
+	}
+	writeBookmarks(t, sources[0].Path, `C:\Work`)
+	writeBookmarks(t, sources[1].Path, `C:\Work`, `\\server\share\Project`)
+	writeBookmarks(t, sources[2].Path, `D:\Shared`)
+	return sources
+}
+
+func TestSourcesFromConfig(t *testing.T) {
+	t.Setenv("AFXW_BM_TEST_SHARE", `\\server\share`)
+
+	configured := []config.BookmarkSource{
+		{Label: "チーム", Path: `%AFXW_BM_TEST_SHARE%\bookmarks.toml`, ReadOnly: true},
+		{Path: `D:\sync\work.toml`},
+		{Label: "重複", Path: `C:\afxw\bookmarks.toml`},
+		{Label: "空", Path: " "},
+	}
+	expected := []bookmark.Source{
+		{Path: `C:\afxw\bookmarks.toml`},
+		{Label: "チーム", Path: `\\server\share\bookmarks.toml`, ReadOnly: true},
+		{Label: "work", Path: `D:\sync\work.toml`},
+	}
+
+	got := sourcesFromConfig(`C:\afxw\bookmarks.toml`, configured)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %+v, 取得: %+v", expected, got)
+	}
+}
+
+func TestRunSelect_Sources(t *testing.T) {
+	sources := layeredSources(t)
+	teamBefore, err := os.ReadFile(sources[1].Path)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+
+	afxMock := &afxtest.MockAFX{}
+	finderMock := &afxtest.MockFinder{Idx: 1}
+	if err := runSelect(t.Context(), afxMock, finderMock, sources, selectOptions{}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	// 同じディレクトリは個人用のものを表示し、他のファイルのものには読み込み元を表示する
+	expectedItems := []string{
+		`Work     C:\Work`,
+		`Project  \\server\share\Project  [チーム]`,
+		`Shared   D:\Shared  [共有]`,
+	}
+	if !reflect.DeepEqual(finderMock.Items, expectedItems) {
+		t.Errorf("期待: %q, 取得: %q", expectedItems, finderMock.Items)
+	}
+	if afxMock.ExcdPath != `\\server\share\Project` {
+		t.Errorf("期待: \\\\server\\share\\Project, 取得: %s", afxMock.ExcdPath)
+	}
+
+	// 読み取り専用のファイルには使用履歴を記録しない
+	teamAfter, err := os.ReadFile(sources[1].Path)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if !bytes.Equal(teamBefore, teamAfter) {
+		t.Error("読み取り専用のファイルが変更されています")
+	}
+}
+
+func TestRunRemove_Sources(t *testing.T) {
+	sources := layeredSources(t)
+
+	err := runRemove(&bytes.Buffer{}, &afxtest.MockFinder{}, sources, []string{"Project"})
+	if !errors.Is(err, bookmark.ErrReadOnly) {
+		t.Errorf("ErrReadOnly が期待されましたが、%v が返りました", err)
+	}
+	if got := loadPaths(t, sources[1].Path); len(got) != 2 {
+		t.Errorf("読み取り専用のファイルが変更されています: %q", got)
+	}
+
+	// 書き込み可能なファイルのブックマークはそのファイルから削除する
+	if err := runRemove(&bytes.Buffer{}, &afxtest.MockFinder{}, sources, []string{"Shared"}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if got := loadPaths(t, sources[2].Path); len(got) != 0 {
+		t.Errorf("削除されていません: %q", got)
+	}
+	if got := loadPaths(t, sources[0].Path); len(got) != 1 {
+		t.Errorf("個人用のファイルが変更されています: %q", got)
+	}
+}
+
+func TestRunRename_Sources(t *testing.T) {
+	sources := layeredSources(t)
+
+	if err := runRename(&bytes.Buffer{}, sources, []string{"Shared", "共有フォルダ"}); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	bookmarks, err := bookmark.Load(sources[2].Path)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if bookmarks[0].Name != "共有フォルダ" {
+		t.Errorf("期待: 共有フォルダ, 取得: %s", bookmarks[0].Name)
+	}
+
+	if err := runAlias(&bytes.Buffer{}, sources, []string{"Project", "p"}); !errors.Is(err, bookmark.ErrReadOnly) {
+		t.Errorf("ErrReadOnly が期待されましたが、%v が返りました", err)
+	}
+}
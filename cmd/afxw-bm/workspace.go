@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/finder"
+	"github.com/tana9/afxw-tools/internal/history"
+	"github.com/tana9/afxw-tools/internal/zoxide"
+	"github.com/urfave/cli/v3"
+)
+
+// workspaceCommand は左右の窓のディレクトリの組を扱うサブコマンドを返します。
+func workspaceCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "ws",
+		Aliases: []string{"workspace"},
+		Usage:   "ワークスペースを選択して左右の窓を移動",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			wsPath, err := workspacePath(cmd)
+			if err != nil {
+				return err
+			}
+			return withAFX(ctx, func(a afx.AFX) error {
+				return runWorkspaceSelect(ctx, a, &finder.TUIFinder{}, wsPath)
+			})
+		},
+		Commands: []*cli.Command{
+			{
+				Name:      "save",
+				Usage:     "左右の窓のディレクトリをワークスペースとして保存（同じ名前のものは上書き）",
+				ArgsUsage: "<名前>",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					if cmd.Args().Len() != 1 {
+						return errors.New("ワークスペースの名前を1つ指定してください")
+					}
+					wsPath, err := workspacePath(cmd)
+					if err != nil {
+						return err
+					}
+					return withAFX(ctx, func(a afx.AFX) error {
+						return runWorkspaceSave(ctx, a, os.Stdout, wsPath, cmd.Args().First(), cmd.Bool("portable"))
+					})
+				},
+			},
+			{
+				Name:      "rm",
+				Usage:     "ワークスペースを削除",
+				ArgsUsage: "<名前>",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					if cmd.Args().Len() != 1 {
+						return errors.New("ワークスペースの名前を1つ指定してください")
+					}
+					wsPath, err := workspacePath(cmd)
+					if err != nil {
+						return err
+					}
+					return runWorkspaceRemove(os.Stdout, wsPath, cmd.Args().First())
+				},
+			},
+			{
+				Name:    "list",
+				Aliases: []string{"ls"},
+				Usage:   "ワークスペースを一覧表示",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					wsPath, err := workspacePath(cmd)
+					if err != nil {
+						return err
+					}
+					return runWorkspaceList(os.Stdout, wsPath)
+				},
+			},
+		},
+	}
+}
+
+// workspacePath は個人用のブックマークファイルと同じディレクトリにあるワークスペースファイルのパスを返します。
+func workspacePath(cmd *cli.Command) (string, error) {
+	bmPath, err := bookmarkPath(cmd)
+	if err != nil {
+		return "", err
+	}
+	return bookmark.WorkspacePath(bmPath), nil
+}
+
+// withAFX はあふwに接続し、移動を履歴（設定によっては zoxide にも）に記録する afx.AFX を fn に渡します。
+func withAFX(ctx context.Context, fn func(a afx.AFX) error) error {
+	a, err := afx.New(ctx)
+	if err != nil {
+		return fmt.Errorf("afxw.obj への接続に失敗しました: %w", err)
+	}
+	defer a.Close()
+	return fn(zoxide.Wrap(history.Wrap(a)))
+}
+
+// runWorkspaceSave は左右の窓のディレクトリを name のワークスペースとして保存します。
+// portable が true の場合はパスを環境変数を使った形式で保存します。
+func runWorkspaceSave(ctx context.Context, a afx.AFX, w io.Writer, wsPath string, name string, portable bool) error {
+	left, err := a.PathOf(ctx, afx.WindowLeft)
+	if err != nil {
+		return fmt.Errorf("左窓のディレクトリの取得に失敗しました: %w", err)
+	}
+	right, err := a.PathOf(ctx, afx.WindowRight)
+	if err != nil {
+		return fmt.Errorf("右窓のディレクトリの取得に失敗しました: %w", err)
+	}
+	if portable {
+		left, right = bookmark.Portable(left), bookmark.Portable(right)
+	}
+
+	ws := bookmark.Workspace{Name: name, Left: left, Right: right, Created: time.Now()}
+	if err := bookmark.SaveWorkspace(wsPath, ws); err != nil {
+		return fmt.Errorf("ワークスペースの保存に失敗しました: %w", err)
+	}
+	fmt.Fprintf(w, "ワークスペースを保存しました: %s  左: %s  右: %s\n", name, left, right)
+	return nil
+}
+
+// runWorkspaceSelect はファインダーで選択したワークスペースの左右のディレクトリへ移動します。
+func runWorkspaceSelect(ctx context.Context, a afx.AFX, f finder.Finder, wsPath string) error {
+	workspaces, err := bookmark.LoadWorkspaces(wsPath)
+	if err != nil {
+		return err
+	}
+	if len(workspaces) == 0 {
+		return errors.New("ワークスペースが見つかりません（afxw-bm ws save <名前> で保存できます）")
+	}
+
+	idx, err := f.Find(bookmark.FormatWorkspaces(workspaces))
+	if err != nil {
+		// ESCやCtrl+Cでキャンセルされた場合は正常終了
+		if errors.Is(err, fuzzyfinder.ErrAbort) {
+			return nil
+		}
+		return err
+	}
+
+	ws := workspaces[idx]
+	if err := afx.JumpPair(ctx, a, ws.Left, ws.Right); err != nil {
+		return fmt.Errorf("ディレクトリ移動に失敗しました: %w", err)
+	}
+	// 使用履歴は左右の窓の移動が済んでから一度だけ記録する。移動自体は成功しているため警告だけにする
+	if err := bookmark.TouchWorkspace(wsPath, ws.Name, time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "警告: ワークスペースの使用履歴の記録に失敗しました: %v\n", err)
+	}
+	return nil
+}
+
+// runWorkspaceRemove は name のワークスペースを削除します。
+func runWorkspaceRemove(w io.Writer, wsPath string, name string) error {
+	if err := bookmark.RemoveWorkspace(wsPath, name); err != nil {
+		return fmt.Errorf("ワークスペースの削除に失敗しました: %w", err)
+	}
+	fmt.Fprintf(w, "ワークスペースを削除しました: %s\n", name)
+	return nil
+}
+
+// runWorkspaceList はワークスペースを "名前  左窓のパス | 右窓のパス" の形式で一覧表示します。
+func runWorkspaceList(w io.Writer, wsPath string) error {
+	workspaces, err := bookmark.LoadWorkspaces(wsPath)
+	if err != nil {
+		return err
+	}
+	for _, line := range bookmark.FormatWorkspaces(workspaces) {
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/cmd/afxw-bm/bookmark"
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+	"github.com/tana9/afxw-tools/internal/fakeafxw"
+)
+
+func TestRunWorkspaceSave(t *testing.T) {
+	wsPath := filepath.Join(t.TempDir(), bookmark.WorkspaceFileName)
+	afxMock := &afxtest.MockAFX{PathsByWin: map[int]string{
+		afx.WindowLeft:  `C:\src`,
+		afx.WindowRight: `\\server\deploy`,
+	}}
+
+	var out bytes.Buffer
+	if err := runWorkspaceSave(t.Context(), afxMock, &out, wsPath, "deploy", false); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	workspaces, err := bookmark.LoadWorkspaces(wsPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(workspaces) != 1 {
+		t.Fatalf("ワークスペースが1件期待されましたが、%d件でした", len(workspaces))
+	}
+	ws := workspaces[0]
+	if ws.Name != "deploy" || ws.Left != `C:\src` || ws.Right != `\\server\deploy` {
+		t.Errorf("保存内容が正しくありません: %+v", ws)
+	}
+	if ws.Created.IsZero() {
+		t.Error("作成日時が記録されていません")
+	}
+}
+
+func TestRunWorkspaceSelect(t *testing.T) {
+	tests := []struct {
+		name      string
+		active    int
+		left      string
+		right     string
+		wantLeft  string
+		wantRight string
+	}{
+		{"左窓がアクティブ", afx.WindowLeft, `C:\Left`, `D:\Right`, `C:\src`, `E:\Deploy`},
+		{"右窓がアクティブ", afx.WindowRight, `C:\Left`, `D:\Right`, `C:\src`, `E:\Deploy`},
+		{"左右が同じディレクトリ", afx.WindowLeft, `C:\src`, `C:\src`, `C:\src`, `E:\Deploy`},
+		{"左右が同じで右窓がアクティブ", afx.WindowRight, `C:\Same`, `C:\Same`, `C:\src`, `E:\Deploy`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wsPath := filepath.Join(t.TempDir(), bookmark.WorkspaceFileName)
+			for _, ws := range []bookmark.Workspace{
+				{Name: "docs", Left: `C:\docs`, Right: `D:\out`},
+				{Name: "deploy", Left: `C:\src`, Right: `E:\Deploy`},
+			} {
+				if err := bookmark.SaveWorkspace(wsPath, ws); err != nil {
+					t.Fatalf("テストファイル作成に失敗しました: %v", err)
+				}
+			}
+
+			s := fakeafxw.New(tt.left, tt.right)
+			s.SetActive(tt.active)
+			a := afxtest.DialFake(t, s)
+
+			if err := runWorkspaceSelect(t.Context(), a, &afxtest.MockFinder{Idx: 1}, wsPath); err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if got := s.Path(afx.WindowLeft); got != tt.wantLeft {
+				t.Errorf("左窓 期待: %s, 取得: %s", tt.wantLeft, got)
+			}
+			if got := s.Path(afx.WindowRight); got != tt.wantRight {
+				t.Errorf("右窓 期待: %s, 取得: %s", tt.wantRight, got)
+			}
+
+			workspaces, err := bookmark.LoadWorkspaces(wsPath)
+			if err != nil {
+				t.Fatalf("読み込みに失敗しました: %v", err)
+			}
+			if workspaces[1].LastUsed.IsZero() {
+				t.Error("使用日時が記録されていません")
+			}
+		})
+	}
+}
+
+// failRightAFX は右窓の移動だけに失敗する AFX です。
+type failRightAFX struct {
+	*afxtest.MockAFX
+}
+
+func (a failRightAFX) EXCDIn(ctx context.Context, win int, path string) error {
+	if win == afx.WindowRight {
+		return errors.New("移動できません")
+	}
+	return a.MockAFX.EXCDIn(ctx, win, path)
+}
+
+func TestRunWorkspaceSelect_PartialJump(t *testing.T) {
+	wsPath := filepath.Join(t.TempDir(), bookmark.WorkspaceFileName)
+	if err := bookmark.SaveWorkspace(wsPath, bookmark.Workspace{Name: "deploy", Left: `C:\src`, Right: `E:\Deploy`}); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	a := failRightAFX{&afxtest.MockAFX{}}
+	if err := runWorkspaceSelect(t.Context(), a, &afxtest.MockFinder{}, wsPath); err == nil {
+		t.Fatal("エラーが期待されましたが、nilが返りました")
+	}
+
+	// 使用履歴は左右の窓の移動が済んでから記録するため、途中で失敗した場合は記録しない
+	workspaces, err := bookmark.LoadWorkspaces(wsPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if !workspaces[0].LastUsed.IsZero() {
+		t.Errorf("移動に失敗した場合は使用日時を記録するべきではありません: %v", workspaces[0].LastUsed)
+	}
+}
+
+func TestRunWorkspaceSelect_Abort(t *testing.T) {
+	wsPath := filepath.Join(t.TempDir(), bookmark.WorkspaceFileName)
+	if err := bookmark.SaveWorkspace(wsPath, bookmark.Workspace{Name: "docs", Left: `C:\docs`, Right: `D:\out`}); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	afxMock := &afxtest.MockAFX{}
+	if err := runWorkspaceSelect(t.Context(), afxMock, &afxtest.MockFinder{Err: fuzzyfinder.ErrAbort}, wsPath); err != nil {
+		t.Errorf("キャンセル時はエラーを返さないべきです: %v", err)
+	}
+	if afxMock.ExcdPath != "" || afxMock.ExcdOppositePath != "" {
+		t.Error("キャンセル時は移動しないべきです")
+	}
+}
+
+func TestRunWorkspaceSelect_Empty(t *testing.T) {
+	wsPath := filepath.Join(t.TempDir(), bookmark.WorkspaceFileName)
+	if err := runWorkspaceSelect(t.Context(), &afxtest.MockAFX{}, &afxtest.MockFinder{}, wsPath); err == nil {
+		t.Error("ワークスペースがない場合はエラーが期待されました")
+	}
+}
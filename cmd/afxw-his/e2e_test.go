@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+	"github.com/tana9/afxw-tools/internal/fakeafxw"
+)
+
+func TestRun_E2E(t *testing.T) {
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	s.SetHistory(afx.WindowLeft, `C:\Left`, `C:\Work`, `D:\Right`)
+	a := afxtest.DialFake(t, s)
+
+	// 重複除去後: C:\Left, C:\Work, D:\Right
+	finderMock := &afxtest.MockFinder{Idx: 1}
+	if err := run(t.Context(), a, finderMock, options{wins: []int{afx.WindowLeft, afx.WindowRight}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := s.Path(afx.WindowLeft); got != `C:\Work` {
+		t.Errorf("expected left path %q, got %q", `C:\Work`, got)
+	}
+}
+
+func TestRun_E2E_Opposite(t *testing.T) {
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	s.SetHistory(afx.WindowLeft, `C:\Left`, `C:\Work`)
+	a := afxtest.DialFake(t, s)
+
+	finderMock := &afxtest.MockFinder{Idx: 1}
+	if err := run(t.Context(), a, finderMock, options{wins: []int{afx.WindowLeft}, opposite: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := s.Path(afx.WindowRight); got != `C:\Work` {
+		t.Errorf("expected right path %q, got %q", `C:\Work`, got)
+	}
+	if got := s.Path(afx.WindowLeft); got != `C:\Left` {
+		t.Errorf("left path should not change, got %q", got)
+	}
+}
+
+func TestRun_E2E_Timeout(t *testing.T) {
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	s.SetDelay(300 * time.Millisecond)
+	a := afxtest.DialFake(t, s)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	err := run(ctx, a, &afxtest.MockFinder{}, options{wins: []int{afx.WindowLeft}})
+	if !errors.Is(err, afx.ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+}
@@ -5,11 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/ktr0731/go-fuzzyfinder"
 	"github.com/tana9/afxw-tools/internal/afx"
 	"github.com/tana9/afxw-tools/internal/finder"
+	"github.com/tana9/afxw-tools/internal/history"
 	"github.com/tana9/afxw-tools/internal/singleinstance"
+	"github.com/tana9/afxw-tools/internal/winpath"
+	"github.com/tana9/afxw-tools/internal/zoxide"
 	"github.com/urfave/cli/v3"
 )
 
@@ -27,30 +31,70 @@ func main() {
 				Usage:   "対象ウィンドウ (left, right, both)",
 				Value:   "both",
 			},
+			&cli.BoolFlag{
+				Name:    "opposite",
+				Aliases: []string{"o"},
+				Usage:   "選択したディレクトリを反対窓で開く",
+			},
+			&cli.BoolFlag{
+				Name:  "all",
+				Usage: "あふwの履歴に加えて、これまでに記録した履歴からも選択",
+			},
+			&cli.StringFlag{
+				Name:    "sort",
+				Aliases: []string{"s"},
+				Usage:   "並び順 (history, frecency, recent, alpha)",
+				Value:   string(history.SortHistory),
+			},
+			&cli.DurationFlag{
+				Name:  "half-life",
+				Usage: "frecency で移動の重みが半分になるまでの期間",
+				Value: history.DefaultHalfLife,
+			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			if err := singleinstance.Acquire("afxw-his"); err != nil {
 				return err
 			}
 
-			a, err := afx.NewOleAFX()
+			a, err := afx.New(ctx)
 			if err != nil {
 				return fmt.Errorf("afxw.objへの接続に失敗しました: %w", err)
 			}
 			defer a.Close()
+			a = zoxide.Wrap(history.Wrap(a))
 
 			wins, err := parseWindowFlag(cmd.String("window"))
 			if err != nil {
 				return err
 			}
 
+			sortMode, err := history.ParseSortMode(cmd.String("sort"))
+			if err != nil {
+				return err
+			}
+
+			opts := options{
+				wins:     wins,
+				opposite: cmd.Bool("opposite"),
+				all:      cmd.Bool("all"),
+				sort:     sortMode,
+				halfLife: cmd.Duration("half-life"),
+			}
+			if path, err := history.DefaultPath(); err == nil {
+				opts.historyPath = path
+			}
+
 			f := &finder.GoFuzzyFinder{}
-			return run(a, f, wins)
+			return run(ctx, a, f, opts)
 		},
 	}
 
 	if err := cmd.Run(context.Background(), os.Args); err != nil {
 		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		if errors.Is(err, afx.ErrTimeout) {
+			fmt.Fprintln(os.Stderr, "あふwがダイアログ表示中などで応答できない可能性があります。")
+		}
 		fmt.Fprintln(os.Stderr, "何かキーを押すと終了します...")
 		fmt.Scanln()
 		os.Exit(1)
@@ -70,15 +114,37 @@ func parseWindowFlag(window string) ([]int, error) {
 	}
 }
 
-func run(a afx.AFX, f finder.Finder, wins []int) error {
+// options は run の動作を指定します。
+type options struct {
+	wins        []int
+	opposite    bool
+	all         bool             // 記録済みの履歴も候補に加える
+	historyPath string           // 履歴ファイルのパス。空の場合は記録しない
+	sort        history.SortMode // 候補の並び順。空の場合はあふwの履歴の順
+	halfLife    time.Duration    // frecency の半減期
+}
+
+func run(ctx context.Context, a afx.AFX, f finder.Finder, opts options) error {
 	// あふのフォルダ履歴取得
-	dirs, err := a.Histories(wins)
+	dirs, err := a.Histories(ctx, opts.wins)
 	if err != nil {
 		return fmt.Errorf("履歴の取得に失敗しました: %w", err)
 	}
 
-	// 重複を除去
-	dirs = removeDuplicates(dirs)
+	// 重複を除去（Windows のパスとして同じものは1つにまとめる）
+	dirs = winpath.Dedup(dirs)
+
+	// 履歴ファイルに記録し、--all の場合は記録済みの履歴を候補に加える
+	store := history.New()
+	if opts.historyPath != "" {
+		store = recordHistories(dirs, opts.historyPath)
+	}
+	if opts.all {
+		dirs = winpath.Dedup(append(dirs, store.Paths()...))
+	}
+
+	// 並べ替え
+	dirs = store.Sort(dirs, opts.sort, time.Now(), opts.halfLife)
 
 	// 候補がなければ何もしない
 	if len(dirs) == 0 {
@@ -96,24 +162,22 @@ func run(a afx.AFX, f finder.Finder, wins []int) error {
 	}
 
 	// フォルダ変更
-	if err := a.EXCD(dirs[idx]); err != nil {
+	if err := afx.Jump(ctx, a, dirs[idx], opts.opposite); err != nil {
 		return fmt.Errorf("ディレクトリ移動に失敗しました: %w", err)
 	}
 
 	return nil
 }
 
-// removeDuplicates はスライスから重複を除去します。出現順序を保持します。
-func removeDuplicates(dirs []string) []string {
-	seen := make(map[string]bool)
-	result := make([]string, 0, len(dirs))
-
-	for _, dir := range dirs {
-		if !seen[dir] {
-			seen[dir] = true
-			result = append(result, dir)
-		}
+// recordHistories はあふwの履歴を履歴ファイルに記録し、記録後のストアを返します。
+// 記録に失敗した場合は警告を表示して空のストアを返します。
+func recordHistories(dirs []string, path string) *history.Store {
+	store, err := history.Update(path, func(s *history.Store) {
+		s.Seen(dirs, time.Now())
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 履歴の記録に失敗しました: %v\n", err)
+		return history.New()
 	}
-
-	return result
+	return store
 }
@@ -2,11 +2,14 @@ package main
 
 import (
 	"errors"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/tana9/afxw-tools/internal/afx"
 	"github.com/tana9/afxw-tools/internal/afxtest"
+	"github.com/tana9/afxw-tools/internal/history"
 )
 
 func TestRun(t *testing.T) {
@@ -81,7 +84,7 @@ func TestRun(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := run(tt.afxMock, tt.finderMock, nil)
+			err := run(t.Context(), tt.afxMock, tt.finderMock, options{})
 
 			if tt.expectErr {
 				if err == nil {
@@ -133,7 +136,7 @@ func TestRun_WinsAffectsHistoryResults(t *testing.T) {
 			}
 			finderMock := &afxtest.MockFinder{Idx: 0}
 
-			err := run(afxMock, finderMock, tt.wins)
+			err := run(t.Context(), afxMock, finderMock, options{wins: tt.wins})
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -178,49 +181,6 @@ func TestParseWindowFlag(t *testing.T) {
 	}
 }
 
-func TestRemoveDuplicates(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    []string
-		expected []string
-	}{
-		{
-			name:     "no duplicates",
-			input:    []string{"C:\\Windows", "C:\\Users", "C:\\Temp"},
-			expected: []string{"C:\\Windows", "C:\\Users", "C:\\Temp"},
-		},
-		{
-			name:     "with duplicates",
-			input:    []string{"C:\\Windows", "C:\\Users", "C:\\Windows", "C:\\Temp"},
-			expected: []string{"C:\\Windows", "C:\\Users", "C:\\Temp"},
-		},
-		{
-			name:     "all duplicates",
-			input:    []string{"C:\\Windows", "C:\\Windows", "C:\\Windows"},
-			expected: []string{"C:\\Windows"},
-		},
-		{
-			name:     "empty slice",
-			input:    []string{},
-			expected: []string{},
-		},
-		{
-			name:     "single element",
-			input:    []string{"C:\\Windows"},
-			expected: []string{"C:\\Windows"},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := removeDuplicates(tt.input)
-			if !reflect.DeepEqual(result, tt.expected) {
-				t.Errorf("expected %v, got %v", tt.expected, result)
-			}
-		})
-	}
-}
-
 func TestRun_WithDuplicates(t *testing.T) {
 	// 左右のウィンドウで重複する履歴がある場合のテスト
 	afxMock := &afxtest.MockAFX{
@@ -228,7 +188,7 @@ func TestRun_WithDuplicates(t *testing.T) {
 	}
 	finderMock := &afxtest.MockFinder{Idx: 1} // "C:\\Users"を選択
 
-	err := run(afxMock, finderMock, []int{afx.WindowLeft, afx.WindowRight})
+	err := run(t.Context(), afxMock, finderMock, options{wins: []int{afx.WindowLeft, afx.WindowRight}})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -238,3 +198,122 @@ func TestRun_WithDuplicates(t *testing.T) {
 		t.Errorf("expected excd path %q, got %q", "C:\\Users", afxMock.ExcdPath)
 	}
 }
+
+func TestRun_WithEquivalentPaths(t *testing.T) {
+	// 大文字小文字や末尾の区切りだけが異なる履歴は同じディレクトリとして扱う
+	afxMock := &afxtest.MockAFX{
+		HistoriesResult: []string{"C:\\Windows", "c:\\windows\\", "C:\\Users", "C:/Users"},
+	}
+	finderMock := &afxtest.MockFinder{Idx: 1}
+
+	err := run(t.Context(), afxMock, finderMock, options{wins: []int{afx.WindowLeft, afx.WindowRight}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if afxMock.ExcdPath != "C:\\Users" {
+		t.Errorf("expected excd path %q, got %q", "C:\\Users", afxMock.ExcdPath)
+	}
+}
+
+func TestRun_Opposite(t *testing.T) {
+	afxMock := &afxtest.MockAFX{
+		HistoriesResult: []string{"C:\\Windows", "C:\\Users"},
+	}
+	finderMock := &afxtest.MockFinder{Idx: 1}
+
+	err := run(t.Context(), afxMock, finderMock, options{opposite: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if afxMock.ExcdOppositePath != "C:\\Users" {
+		t.Errorf("expected opposite excd path %q, got %q", "C:\\Users", afxMock.ExcdOppositePath)
+	}
+	if afxMock.ExcdPath != "" {
+		t.Errorf("active window should not be changed, got %q", afxMock.ExcdPath)
+	}
+}
+
+func TestRun_RecordsHistories(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "history.json")
+	afxMock := &afxtest.MockAFX{
+		HistoriesResult: []string{"C:\\Windows", "C:\\Users"},
+	}
+	finderMock := &afxtest.MockFinder{Idx: 0}
+
+	if err := run(t.Context(), afxMock, finderMock, options{historyPath: historyPath}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	store, err := history.Load(historyPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.Entries()) != 2 {
+		t.Errorf("expected 2 recorded entries, got %v", store.Entries())
+	}
+}
+
+func TestRun_All(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "history.json")
+	old := time.Now().Add(-time.Hour)
+	if _, err := history.Update(historyPath, func(s *history.Store) {
+		s.Seen([]string{"C:\\Old", "C:\\Windows"}, old)
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	afxMock := &afxtest.MockAFX{
+		HistoriesResult: []string{"C:\\Windows"},
+	}
+	// あふwの履歴の後ろに記録済みの履歴が続く: C:\Windows, C:\Old
+	finderMock := &afxtest.MockFinder{Idx: 1}
+
+	if err := run(t.Context(), afxMock, finderMock, options{all: true, historyPath: historyPath}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if afxMock.ExcdPath != "C:\\Old" {
+		t.Errorf("expected excd path %q, got %q", "C:\\Old", afxMock.ExcdPath)
+	}
+}
+
+func TestRun_SortFrecency(t *testing.T) {
+	historyPath := filepath.Join(t.TempDir(), "history.json")
+	now := time.Now()
+	if _, err := history.Update(historyPath, func(s *history.Store) {
+		for range 3 {
+			s.Visit("C:\\Users", now)
+		}
+		s.Visit("C:\\Temp", now)
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	afxMock := &afxtest.MockAFX{
+		HistoriesResult: []string{"C:\\Windows", "C:\\Temp", "C:\\Users"},
+	}
+	finderMock := &afxtest.MockFinder{Idx: 0}
+
+	opts := options{historyPath: historyPath, sort: history.SortFrecency, halfLife: history.DefaultHalfLife}
+	if err := run(t.Context(), afxMock, finderMock, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if afxMock.ExcdPath != "C:\\Users" {
+		t.Errorf("expected excd path %q, got %q", "C:\\Users", afxMock.ExcdPath)
+	}
+}
+
+func TestRun_SortAlphaWithoutHistoryFile(t *testing.T) {
+	afxMock := &afxtest.MockAFX{
+		HistoriesResult: []string{"C:\\Windows", "C:\\Users"},
+	}
+	finderMock := &afxtest.MockFinder{Idx: 0}
+
+	if err := run(t.Context(), afxMock, finderMock, options{sort: history.SortAlpha}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if afxMock.ExcdPath != "C:\\Users" {
+		t.Errorf("expected excd path %q, got %q", "C:\\Users", afxMock.ExcdPath)
+	}
+}
@@ -1,3 +1,7 @@
+// Package config は afxw-launcher のメニューの設定ファイルを読み込みます。
+//
+// 設定ファイルは ~/.config/afxw-launcher/config.toml（または実行ファイルと同じディレクトリの config.toml）です。
+// afxw-bm などが共有する %APPDATA%\afxw-tools\config.toml（internal/config）とは別のファイルです。
 package config
 
 import (
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/internal/finder"
+	"github.com/tana9/afxw-tools/internal/winpath"
+	"github.com/tana9/afxw-tools/internal/zoxide"
+	"github.com/urfave/cli/v3"
+)
+
+// commands はzoxideのデータベースを直接編集するサブコマンドを返します。
+func commands() []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:      "add",
+			Usage:     "ディレクトリへの移動をzoxideデータベースに記録（zoxide add と同じ）",
+			ArgsUsage: "<パス...>",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				dbPath, err := zoxide.DBPath()
+				if err != nil {
+					return err
+				}
+				cwd, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("カレントディレクトリの取得に失敗しました: %w", err)
+				}
+				return runAdd(os.Stdout, dbPath, cwd, cmd.Args().Slice(), time.Now())
+			},
+		},
+		{
+			Name:      "rm",
+			Usage:     "zoxideデータベースからディレクトリを削除（省略時はファインダーで選択）",
+			ArgsUsage: "[パス...]",
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				dbPath, err := zoxide.DBPath()
+				if err != nil {
+					return err
+				}
+				return runRemove(os.Stdout, &finder.GoFuzzyFinder{}, dbPath, cmd.Args().Slice())
+			},
+		},
+	}
+}
+
+// runAdd は paths への移動をzoxideデータベースに記録します。相対パスは cwd を基準にします。
+// zoxide add と同じく _ZO_EXCLUDE_DIRS に一致するディレクトリは記録しません。
+func runAdd(w io.Writer, dbPath string, cwd string, paths []string, now time.Time) error {
+	if len(paths) == 0 {
+		return errors.New("記録するディレクトリを指定してください")
+	}
+	exclude, err := zoxide.ExcludeDirs()
+	if err != nil {
+		return err
+	}
+	maxAge, err := zoxide.MaxAge()
+	if err != nil {
+		return err
+	}
+
+	var dirs []string
+	for _, p := range paths {
+		dir := winpath.Abs(cwd, p)
+		if exclude.Match(dir) {
+			fmt.Fprintf(w, "%s に一致するため記録しません: %s\n", zoxide.EnvExcludeDirs, dir)
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	err = zoxide.UpdateDB(dbPath, func(db []zoxide.Dir) ([]zoxide.Dir, error) {
+		for _, dir := range dirs {
+			db = zoxide.Add(db, dir, 1, now)
+		}
+		return zoxide.Age(db, maxAge), nil
+	})
+	if err != nil {
+		return fmt.Errorf("zoxideデータベースへの記録に失敗しました: %w", err)
+	}
+	for _, dir := range dirs {
+		fmt.Fprintf(w, "zoxideに記録しました: %s\n", dir)
+	}
+	return nil
+}
+
+// runRemove は paths をzoxideデータベースから削除します。
+// paths が空の場合はファインダーで削除するディレクトリを選択します。
+func runRemove(w io.Writer, f finder.Finder, dbPath string, paths []string) error {
+	if len(paths) == 0 {
+		dirs, err := zoxide.ReadDB(dbPath)
+		if err != nil {
+			return err
+		}
+		if len(dirs) == 0 {
+			fmt.Fprintln(w, "zoxideデータベースにディレクトリが見つかりません。")
+			return nil
+		}
+		entries := zoxide.Rank(dirs, time.Now(), func(string) bool { return true })
+		idx, err := f.Find(zoxide.Paths(entries))
+		if err != nil {
+			// ESCやCtrl+Cでキャンセルされた場合は正常終了
+			if errors.Is(err, fuzzyfinder.ErrAbort) {
+				return nil
+			}
+			return err
+		}
+		paths = []string{entries[idx].Path}
+	}
+
+	err := zoxide.UpdateDB(dbPath, func(db []zoxide.Dir) ([]zoxide.Dir, error) {
+		for _, p := range paths {
+			var ok bool
+			if db, ok = zoxide.Remove(db, p); !ok {
+				return nil, fmt.Errorf("zoxideデータベースに見つかりません: %s", p)
+			}
+		}
+		return db, nil
+	})
+	if err != nil {
+		return fmt.Errorf("zoxideデータベースからの削除に失敗しました: %w", err)
+	}
+	for _, p := range paths {
+		fmt.Fprintf(w, "zoxideから削除しました: %s\n", p)
+	}
+	return nil
+}
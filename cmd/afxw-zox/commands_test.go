@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+	"github.com/tana9/afxw-tools/internal/zoxide"
+)
+
+// dbPaths はzoxideのデータベースに記録されたパスを返します。
+func dbPaths(t *testing.T, dbPath string) []string {
+	t.Helper()
+	dirs, err := zoxide.ReadDB(dbPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	paths := make([]string, len(dirs))
+	for i, d := range dirs {
+		paths[i] = d.Path
+	}
+	return paths
+}
+
+func TestRunAdd(t *testing.T) {
+	t.Setenv(zoxide.EnvExcludeDirs, `C:\Temp\*`)
+	dbPath := filepath.Join(t.TempDir(), zoxide.DBFileName)
+	now := time.Unix(1767225600, 0)
+
+	// 相対パスはカレントディレクトリを基準にし、同じディレクトリは1件にまとめる
+	var buf bytes.Buffer
+	if err := runAdd(&buf, dbPath, `C:\Work`, []string{`src`, `C:\Work\src\`, `C:\Temp\build`}, now); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	dirs, err := zoxide.ReadDB(dbPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	expected := []zoxide.Dir{{Path: `C:\Work\src`, Rank: 2, LastAccessed: now}}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("期待: %+v, 取得: %+v", expected, dirs)
+	}
+	// _ZO_EXCLUDE_DIRS に一致するディレクトリは記録せず、その旨を表示する
+	if !strings.Contains(buf.String(), `記録しません: C:\Temp\build`) {
+		t.Errorf("除外したディレクトリが表示されていません: %q", buf.String())
+	}
+
+	if err := runAdd(&bytes.Buffer{}, dbPath, `C:\Work`, nil, now); err == nil {
+		t.Error("パスを指定しない場合はエラーが期待されました")
+	}
+}
+
+func TestRunRemove(t *testing.T) {
+	now := time.Now()
+	initial := []zoxide.Dir{
+		{Path: `C:\code`, Rank: 1, LastAccessed: now},
+		{Path: `C:\docs`, Rank: 10, LastAccessed: now},
+		{Path: `C:\work`, Rank: 5, LastAccessed: now},
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		finder   *afxtest.MockFinder
+		expected []string
+		wantErr  bool
+	}{
+		{name: "パスを指定", args: []string{`C:\code`}, finder: &afxtest.MockFinder{}, expected: []string{`C:\docs`, `C:\work`}},
+		// ファインダーにはスコアの高い順に表示する
+		{name: "ファインダーで選択", finder: &afxtest.MockFinder{Idx: 1}, expected: []string{`C:\code`, `C:\docs`}},
+		{name: "キャンセル", finder: &afxtest.MockFinder{Err: fuzzyfinder.ErrAbort}, expected: []string{`C:\code`, `C:\docs`, `C:\work`}},
+		{name: "見つからない", args: []string{`C:\none`}, finder: &afxtest.MockFinder{}, expected: []string{`C:\code`, `C:\docs`, `C:\work`}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbPath := filepath.Join(t.TempDir(), zoxide.DBFileName)
+			if err := zoxide.WriteDB(dbPath, initial); err != nil {
+				t.Fatalf("テストファイル作成に失敗しました: %v", err)
+			}
+
+			err := runRemove(&bytes.Buffer{}, tt.finder, dbPath, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("エラー 期待: %v, 取得: %v", tt.wantErr, err)
+			}
+			if got := dbPaths(t, dbPath); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
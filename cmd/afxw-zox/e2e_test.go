@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+	"github.com/tana9/afxw-tools/internal/fakeafxw"
+	"github.com/tana9/afxw-tools/internal/zoxide"
+)
+
+func TestRun_E2E(t *testing.T) {
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	a := afxtest.DialFake(t, s)
+
+	query := makeQuery([]zoxide.Entry{
+		{Path: `C:\Users\Test`, Score: 20.0},
+		{Path: `C:\Projects`, Score: 10.0},
+	}, nil)
+
+	if err := run(t.Context(), a, &afxtest.MockFinder{Idx: 1}, query, true); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	if got := s.Path(afx.WindowRight); got != `C:\Projects` {
+		t.Errorf("期待: C:\\Projects, 取得: %s", got)
+	}
+}
@@ -1,60 +1,261 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"os/exec"
+	"io"
 	"strings"
 	"time"
 
 	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/history"
+	"github.com/tana9/afxw-tools/internal/winpath"
+	"github.com/tana9/afxw-tools/internal/zoxide"
 )
 
+// importOptions はインポートの指定です。
+type importOptions struct {
+	dbPath      string // zoxideのデータベースファイル
+	historyPath string // 移動回数と日時を参照する afxw-tools の履歴ファイル
+	statePath   string // インポート済みのディレクトリを記録するファイル
+	marked      bool   // 履歴の代わりにマークされたディレクトリをインポートする
+	dryRun      bool   // インポートする内容を表示するだけでデータベースを変更しない
+	recording   bool   // 設定ファイルの [zoxide] record = true により、移動をその都度zoxideに記録している
+}
+
+// ringDir はあふwの履歴のディレクトリと、履歴での位置から求めた重みです。
+type ringDir struct {
+	path   string
+	weight float64 // 履歴の先頭（最も新しい）が 1、末尾が 1/履歴の件数
+}
+
+// importItem はzoxideのデータベースに取り込むディレクトリです。
+type importItem struct {
+	dir   zoxide.Dir
+	count int // afxw-tools が記録した移動回数（記録がない場合は 0）
+}
+
+// importPlan はインポートの内容です。
+type importPlan struct {
+	items    []importItem
+	recorded []importedDir // 移動をその都度zoxideに記録しているため、取り込まずにインポート済みとするディレクトリ
+	skipped  int           // インポート済みで、その後の移動がないため取り込まないディレクトリの数
+	excluded int           // _ZO_EXCLUDE_DIRS に一致したため取り込まないディレクトリの数
+}
+
+// note は取り込まないディレクトリの件数を "（インポート済み 3件、zoxideに記録済み 2件、除外 1件）" の形式で返します。
+func (p importPlan) note() string {
+	var parts []string
+	if p.skipped > 0 {
+		parts = append(parts, fmt.Sprintf("インポート済み %d件", p.skipped))
+	}
+	if len(p.recorded) > 0 {
+		parts = append(parts, fmt.Sprintf("zoxideに記録済み %d件", len(p.recorded)))
+	}
+	if p.excluded > 0 {
+		parts = append(parts, fmt.Sprintf("除外 %d件", p.excluded))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "（" + strings.Join(parts, "、") + "）"
+}
+
 // runImport はあふwの履歴をzoxideデータベースにインポートします。
-func runImport(a afx.AFX) error {
-	dirs, err := a.Histories([]int{afx.WindowLeft, afx.WindowRight})
+// 履歴のディレクトリは前回までのインポート以降に afxw-tools が記録した移動回数をランク、最後に移動した日時を
+// そのまま使って取り込みます。記録のないディレクトリはあふwの履歴での位置に応じた重みをランクとして一度だけ取り込みます。
+// marked が true の場合は履歴の代わりにマークされたディレクトリを zoxide add と同じく1回の移動として記録します。
+// いずれも _ZO_EXCLUDE_DIRS に一致するディレクトリは取り込みません。
+// opts.recording が true の場合、afxw-tools が記録した移動はすでにzoxideに記録されているため取り込まず、
+// インポート済みの移動回数だけを進めます。
+func runImport(ctx context.Context, a afx.AFX, w io.Writer, opts importOptions) error {
+	var ring []ringDir
+	var err error
+	if opts.marked {
+		ring, err = markedRing(ctx, a)
+	} else {
+		ring, err = historyRing(ctx, a)
+	}
 	if err != nil {
-		return fmt.Errorf("履歴の取得に失敗しました: %w", err)
+		return err
 	}
 
-	dirs = removeDuplicates(dirs)
-
-	if len(dirs) == 0 {
-		fmt.Println("インポートする履歴がありません。")
+	if len(ring) == 0 {
+		fmt.Fprintln(w, "インポートするディレクトリがありません。")
 		return nil
 	}
 
-	// z形式の一時ファイルに書き込む
-	tmpFile, err := os.CreateTemp("", "afxw-his-*.txt")
+	exclude, err := zoxide.ExcludeDirs()
+	if err != nil {
+		return err
+	}
+	maxAge, err := zoxide.MaxAge()
 	if err != nil {
-		return fmt.Errorf("一時ファイルの作成に失敗しました: %w", err)
+		return err
 	}
-	defer os.Remove(tmpFile.Name())
+	now := time.Now()
 
-	if _, err := tmpFile.WriteString(buildZFormat(dirs, time.Now().Unix())); err != nil {
-		tmpFile.Close()
-		return fmt.Errorf("一時ファイルへの書き込みに失敗しました: %w", err)
+	var plan importPlan
+	var state importState
+	if opts.marked {
+		plan = markedDirs(ring, exclude, now)
+	} else {
+		store, err := history.Load(opts.historyPath)
+		if err != nil {
+			return err
+		}
+		if state, err = loadImportState(opts.statePath); err != nil {
+			return err
+		}
+		plan = importDirs(ring, store.Entries(), state, exclude, opts.recording, now)
 	}
-	tmpFile.Close()
 
-	// zoxide import --from z --merge <tmpfile>
-	zoxCmd := exec.Command("zoxide", "import", "--from", "z", "--merge", tmpFile.Name())
-	zoxCmd.Stdout = os.Stdout
-	zoxCmd.Stderr = os.Stderr
-	if err := zoxCmd.Run(); err != nil {
-		return fmt.Errorf("zoxide importの実行に失敗しました: %w", err)
+	if opts.dryRun {
+		for _, it := range plan.items {
+			fmt.Fprintf(w, "+%.2f  %s\n", it.dir.Rank, it.dir.Path)
+		}
+		fmt.Fprintf(w, "%d件のディレクトリをzoxideにインポートします。%s（--dry-run のため変更していません）\n", len(plan.items), plan.note())
+		return nil
+	}
+
+	if len(plan.items) == 0 {
+		fmt.Fprintf(w, "新しくインポートするディレクトリはありません。%s\n", plan.note())
+		return plan.saveState(state, opts.statePath, now)
 	}
 
-	fmt.Printf("%d件の履歴をzoxideにインポートしました。\n", len(dirs))
+	imported := make([]zoxide.Dir, len(plan.items))
+	for i, it := range plan.items {
+		imported[i] = it.dir
+	}
+	err = zoxide.UpdateDB(opts.dbPath, func(db []zoxide.Dir) ([]zoxide.Dir, error) {
+		return zoxide.Age(zoxide.Merge(db, imported), maxAge), nil
+	})
+	if err != nil {
+		return fmt.Errorf("zoxideデータベースへのインポートに失敗しました: %w", err)
+	}
+	fmt.Fprintf(w, "%d件のディレクトリをzoxideにインポートしました。%s\n", len(plan.items), plan.note())
+
+	if opts.marked {
+		return nil
+	}
+	return plan.saveState(state, opts.statePath, now)
+}
+
+// saveState は取り込んだディレクトリとインポート済みとしたディレクトリを state に加えて path に保存します。
+// 記録するものがない場合は何もしません。
+func (p importPlan) saveState(state importState, path string, now time.Time) error {
+	if len(p.items) == 0 && len(p.recorded) == 0 {
+		return nil
+	}
+	for _, it := range p.items {
+		state[winpath.Key(it.dir.Path)] = importedDir{Path: it.dir.Path, Count: it.count, Imported: now}
+	}
+	for _, d := range p.recorded {
+		state[winpath.Key(d.Path)] = d
+	}
+	if err := state.save(path); err != nil {
+		return fmt.Errorf("インポート済みの記録の保存に失敗しました（次回のインポートで同じ移動を再び取り込みます）: %w", err)
+	}
 	return nil
 }
 
-// buildZFormat はパス一覧をz.sh形式の文字列に変換します。
-// 形式: パス|ランク|タイムスタンプ
-func buildZFormat(paths []string, timestamp int64) string {
-	var sb strings.Builder
-	for _, p := range paths {
-		fmt.Fprintf(&sb, "%s|1|%d\n", p, timestamp)
+// historyRing はあふwの左右の窓の履歴を取得し、窓ごとの履歴での位置から重みを求めます。
+// 両方の窓にあるディレクトリは重みの大きい方を使います。
+func historyRing(ctx context.Context, a afx.AFX) ([]ringDir, error) {
+	var ring []ringDir
+	seen := make(map[string]int)
+	for _, win := range []int{afx.WindowLeft, afx.WindowRight} {
+		dirs, err := a.Histories(ctx, []int{win})
+		if err != nil {
+			return nil, fmt.Errorf("履歴の取得に失敗しました: %w", err)
+		}
+		for i, dir := range dirs {
+			weight := float64(len(dirs)-i) / float64(len(dirs))
+			key := winpath.Key(dir)
+			if j, ok := seen[key]; ok {
+				ring[j].weight = max(ring[j].weight, weight)
+				continue
+			}
+			seen[key] = len(ring)
+			ring = append(ring, ringDir{path: winpath.Clean(dir), weight: weight})
+		}
+	}
+	return ring, nil
+}
+
+// markedRing はマークされたディレクトリ（なければアクティブパス）を重み 1 で返します。
+func markedRing(ctx context.Context, a afx.AFX) ([]ringDir, error) {
+	dirs, err := afx.TargetDirs(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	dirs = winpath.Dedup(dirs)
+	ring := make([]ringDir, len(dirs))
+	for i, dir := range dirs {
+		ring[i] = ringDir{path: winpath.Clean(dir), weight: 1}
+	}
+	return ring, nil
+}
+
+// importDirs は履歴のディレクトリのうち、zoxideのデータベースに取り込むものを決めます。
+// afxw-tools の履歴に移動した記録があるディレクトリは state に記録したインポート済みの移動回数との差をランク、
+// 最後に移動した日時を日時とし、差がなければ取り込みません。
+// 記録がないディレクトリは state になければ履歴での位置の重みをランク、now を日時として取り込みます。
+// recording が true の場合、移動した記録があるディレクトリは取り込まずに記録の移動回数をインポート済みとします。
+func importDirs(ring []ringDir, recorded []history.Entry, state importState, exclude zoxide.Exclude, recording bool, now time.Time) importPlan {
+	visits := make(map[string]history.Entry, len(recorded))
+	for _, e := range recorded {
+		if e.Count > 0 && !e.LastVisit.IsZero() {
+			visits[winpath.Key(e.Path)] = e
+		}
+	}
+
+	var plan importPlan
+	for _, r := range ring {
+		if exclude.Match(r.path) {
+			plan.excluded++
+			continue
+		}
+		key := winpath.Key(r.path)
+		prev, imported := state[key]
+		if e, ok := visits[key]; ok {
+			// 履歴ファイルを作り直した場合は、記録されている移動をすべて取り込む
+			if e.Count < prev.Count {
+				prev.Count = 0
+			}
+			n := e.Count - prev.Count
+			if n > 0 && recording {
+				plan.recorded = append(plan.recorded, importedDir{Path: r.path, Count: e.Count, Imported: now})
+				continue
+			}
+			if n > 0 {
+				plan.items = append(plan.items, importItem{
+					dir:   zoxide.Dir{Path: r.path, Rank: float64(n), LastAccessed: e.LastVisit},
+					count: e.Count,
+				})
+				continue
+			}
+			plan.skipped++
+			continue
+		}
+		if imported {
+			plan.skipped++
+			continue
+		}
+		plan.items = append(plan.items, importItem{dir: zoxide.Dir{Path: r.path, Rank: r.weight, LastAccessed: now}})
+	}
+	return plan
+}
+
+// markedDirs はマークされたディレクトリを zoxide add と同じく1回の移動として取り込みます。
+func markedDirs(ring []ringDir, exclude zoxide.Exclude, now time.Time) importPlan {
+	var plan importPlan
+	for _, r := range ring {
+		if exclude.Match(r.path) {
+			plan.excluded++
+			continue
+		}
+		plan.items = append(plan.items, importItem{dir: zoxide.Dir{Path: r.path, Rank: r.weight, LastAccessed: now}})
 	}
-	return sb.String()
+	return plan
 }
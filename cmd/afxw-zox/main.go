@@ -7,10 +7,12 @@ import (
 	"os"
 
 	"github.com/ktr0731/go-fuzzyfinder"
-	"github.com/tana9/afxw-tools/cmd/afxw-zox/zoxide"
 	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/config"
 	"github.com/tana9/afxw-tools/internal/finder"
+	"github.com/tana9/afxw-tools/internal/history"
 	"github.com/tana9/afxw-tools/internal/singleinstance"
+	"github.com/tana9/afxw-tools/internal/zoxide"
 	"github.com/urfave/cli/v3"
 )
 
@@ -18,44 +20,91 @@ var version = "dev"
 
 func main() {
 	cmd := &cli.Command{
-		Name:    "afxw-zox",
-		Usage:   "zoxideのfrecencyデータベースから選択してあふwで移動",
-		Version: version,
+		Name:      "afxw-zox",
+		Usage:     "zoxideのfrecencyデータベースから選択してあふwで移動",
+		Version:   version,
+		ArgsUsage: "[キーワード...]",
+		Commands:  commands(),
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:    "import-history",
 				Aliases: []string{"i"},
 				Usage:   "あふwの履歴をzoxideデータベースにインポート",
 			},
+			&cli.BoolFlag{
+				Name:    "marked",
+				Aliases: []string{"m"},
+				Usage:   "-i と併用し、履歴の代わりにマークされたディレクトリ（なければアクティブパス）をインポート",
+			},
+			&cli.BoolFlag{
+				Name:    "dry-run",
+				Aliases: []string{"n"},
+				Usage:   "-i と併用し、インポートする内容を表示するだけでデータベースを変更しない",
+			},
+			&cli.BoolFlag{
+				Name:    "opposite",
+				Aliases: []string{"o"},
+				Usage:   "選択したディレクトリを反対窓で開く",
+			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
-			a, err := afx.NewOleAFX()
+			a, err := afx.New(ctx)
 			if err != nil {
 				return fmt.Errorf("afxw.objへの接続に失敗しました: %w", err)
 			}
 			defer a.Close()
+			a = zoxide.Wrap(history.Wrap(a))
 
 			if cmd.Bool("import-history") {
-				return runImport(a)
+				dbPath, err := zoxide.DBPath()
+				if err != nil {
+					return err
+				}
+				historyPath, err := history.DefaultPath()
+				if err != nil {
+					return err
+				}
+				statePath, err := defaultStatePath()
+				if err != nil {
+					return err
+				}
+				cfg, err := config.LoadDefault()
+				if err != nil {
+					return err
+				}
+				return runImport(ctx, a, os.Stdout, importOptions{
+					dbPath:      dbPath,
+					historyPath: historyPath,
+					statePath:   statePath,
+					marked:      cmd.Bool("marked"),
+					dryRun:      cmd.Bool("dry-run"),
+					recording:   cfg.Zoxide.Record,
+				})
 			}
 
 			if err := singleinstance.Acquire("afxw-zox"); err != nil {
 				return err
 			}
 
-			return run(a, &finder.GoFuzzyFinder{}, zoxide.Query)
+			if cmd.Args().Present() {
+				return runKeywords(ctx, a, &finder.GoFuzzyFinder{}, zoxide.Query, cmd.Args().Slice(), cmd.Bool("opposite"))
+			}
+			return run(ctx, a, &finder.GoFuzzyFinder{}, zoxide.Query, cmd.Bool("opposite"))
 		},
 	}
 
 	if err := cmd.Run(context.Background(), os.Args); err != nil {
 		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		if errors.Is(err, afx.ErrTimeout) {
+			fmt.Fprintln(os.Stderr, "あふwがダイアログ表示中などで応答できない可能性があります。")
+		}
 		fmt.Fprintln(os.Stderr, "何かキーを押すと終了します...")
 		fmt.Scanln()
 		os.Exit(1)
 	}
 }
 
-func run(a afx.AFX, f finder.Finder, query func() ([]zoxide.Entry, error)) error {
+func run(ctx context.Context, a afx.AFX, f finder.Finder, query func() ([]zoxide.Entry, error), opposite bool) error {
 	entries, err := query()
 	if err != nil {
 		return fmt.Errorf("zoxideデータベースの取得に失敗しました: %w", err)
@@ -77,22 +126,9 @@ func run(a afx.AFX, f finder.Finder, query func() ([]zoxide.Entry, error)) error
 		return err
 	}
 
-	if err := a.EXCD(paths[idx]); err != nil {
+	if err := afx.Jump(ctx, a, paths[idx], opposite); err != nil {
 		return fmt.Errorf("ディレクトリ移動に失敗しました: %w", err)
 	}
 
 	return nil
 }
-
-// removeDuplicates はスライスから重複を除去します。出現順序を保持します。
-func removeDuplicates(dirs []string) []string {
-	seen := make(map[string]bool)
-	result := make([]string, 0, len(dirs))
-	for _, dir := range dirs {
-		if !seen[dir] {
-			seen[dir] = true
-			result = append(result, dir)
-		}
-	}
-	return result
-}
@@ -1,15 +1,35 @@
 package main
 
 import (
+	"bytes"
 	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ktr0731/go-fuzzyfinder"
-	"github.com/tana9/afxw-tools/cmd/afxw-zox/zoxide"
+	"github.com/tana9/afxw-tools/internal/afx"
 	"github.com/tana9/afxw-tools/internal/afxtest"
+	"github.com/tana9/afxw-tools/internal/history"
+	"github.com/tana9/afxw-tools/internal/zoxide"
 )
 
+// testImportOptions は一時ディレクトリのデータベースと履歴ファイルを使うインポートの指定を返します。
+func testImportOptions(t *testing.T, marked bool) importOptions {
+	t.Helper()
+	tmpDir := t.TempDir()
+	return importOptions{
+		dbPath:      filepath.Join(tmpDir, zoxide.DBFileName),
+		historyPath: filepath.Join(tmpDir, "history.json"),
+		statePath:   filepath.Join(tmpDir, importStateFileName),
+		marked:      marked,
+	}
+}
+
 func makeQuery(entries []zoxide.Entry, err error) func() ([]zoxide.Entry, error) {
 	return func() ([]zoxide.Entry, error) {
 		return entries, err
@@ -24,7 +44,7 @@ func TestRun_Normal(t *testing.T) {
 		{Path: `C:\Projects`, Score: 20.0},
 	}, nil)
 
-	if err := run(afxMock, finderMock, query); err != nil {
+	if err := run(t.Context(), afxMock, finderMock, query, false); err != nil {
 		t.Fatalf("予期しないエラー: %v", err)
 	}
 
@@ -38,7 +58,7 @@ func TestRun_EmptyEntries(t *testing.T) {
 	finderMock := &afxtest.MockFinder{}
 	query := makeQuery([]zoxide.Entry{}, nil)
 
-	if err := run(afxMock, finderMock, query); err != nil {
+	if err := run(t.Context(), afxMock, finderMock, query, false); err != nil {
 		t.Fatalf("予期しないエラー: %v", err)
 	}
 
@@ -52,7 +72,7 @@ func TestRun_QueryError(t *testing.T) {
 	finderMock := &afxtest.MockFinder{}
 	query := makeQuery(nil, errors.New("query error"))
 
-	err := run(afxMock, finderMock, query)
+	err := run(t.Context(), afxMock, finderMock, query, false)
 	if err == nil {
 		t.Fatal("エラーが期待されましたが、nilが返りました")
 	}
@@ -66,7 +86,7 @@ func TestRun_FinderCancelled(t *testing.T) {
 	finderMock := &afxtest.MockFinder{Err: fuzzyfinder.ErrAbort}
 	query := makeQuery([]zoxide.Entry{{Path: `C:\Users\Test`, Score: 10.0}}, nil)
 
-	if err := run(afxMock, finderMock, query); err != nil {
+	if err := run(t.Context(), afxMock, finderMock, query, false); err != nil {
 		t.Fatalf("キャンセルはエラーになるべきではありません: %v", err)
 	}
 }
@@ -76,7 +96,7 @@ func TestRun_FinderError(t *testing.T) {
 	finderMock := &afxtest.MockFinder{Err: errors.New("finder error")}
 	query := makeQuery([]zoxide.Entry{{Path: `C:\Users\Test`, Score: 10.0}}, nil)
 
-	if err := run(afxMock, finderMock, query); err == nil {
+	if err := run(t.Context(), afxMock, finderMock, query, false); err == nil {
 		t.Fatal("エラーが期待されましたが、nilが返りました")
 	}
 }
@@ -86,7 +106,7 @@ func TestRun_ExcdError(t *testing.T) {
 	finderMock := &afxtest.MockFinder{Idx: 0}
 	query := makeQuery([]zoxide.Entry{{Path: `C:\Users\Test`, Score: 10.0}}, nil)
 
-	err := run(afxMock, finderMock, query)
+	err := run(t.Context(), afxMock, finderMock, query, false)
 	if err == nil {
 		t.Fatal("エラーが期待されましたが、nilが返りました")
 	}
@@ -95,26 +115,24 @@ func TestRun_ExcdError(t *testing.T) {
 	}
 }
 
-func TestBuildZFormat(t *testing.T) {
-	paths := []string{`C:\Users\Test`, `C:\Projects`}
-	got := buildZFormat(paths, 1234567890)
+func TestRun_Opposite(t *testing.T) {
+	afxMock := &afxtest.MockAFX{}
+	finderMock := &afxtest.MockFinder{Idx: 0}
+	query := makeQuery([]zoxide.Entry{{Path: `C:\Users\Test`, Score: 10.0}}, nil)
 
-	lines := strings.Split(strings.TrimRight(got, "\n"), "\n")
-	if len(lines) != 2 {
-		t.Fatalf("期待する行数: 2, 取得: %d", len(lines))
-	}
-	if lines[0] != `C:\Users\Test|1|1234567890` {
-		t.Errorf("予期しない1行目: %s", lines[0])
+	if err := run(t.Context(), afxMock, finderMock, query, true); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
 	}
-	if lines[1] != `C:\Projects|1|1234567890` {
-		t.Errorf("予期しない2行目: %s", lines[1])
+
+	if afxMock.ExcdOppositePath != `C:\Users\Test` {
+		t.Errorf("期待: C:\\Users\\Test, 取得: %s", afxMock.ExcdOppositePath)
 	}
 }
 
 func TestRunImport_HistoriesError(t *testing.T) {
 	afxMock := &afxtest.MockAFX{HistoriesErr: errors.New("history error")}
 
-	err := runImport(afxMock)
+	err := runImport(t.Context(), afxMock, io.Discard, testImportOptions(t, false))
 	if err == nil {
 		t.Fatal("エラーが期待されましたが、nilが返りました")
 	}
@@ -123,9 +141,254 @@ func TestRunImport_HistoriesError(t *testing.T) {
 func TestRunImport_EmptyHistory(t *testing.T) {
 	afxMock := &afxtest.MockAFX{HistoriesResult: []string{}}
 
-	// 履歴が空の場合はzoxideを呼ばずに正常終了する
-	err := runImport(afxMock)
+	// 履歴が空の場合はデータベースを変更せずに正常終了する
+	err := runImport(t.Context(), afxMock, io.Discard, testImportOptions(t, false))
 	if err != nil {
 		t.Fatalf("予期しないエラー: %v", err)
 	}
 }
+
+func TestRunImport_MarkedError(t *testing.T) {
+	afxMock := &afxtest.MockAFX{MarkedFilesErr: errors.New("marked error")}
+
+	if err := runImport(t.Context(), afxMock, io.Discard, testImportOptions(t, true)); err == nil {
+		t.Fatal("エラーが期待されましたが、nilが返りました")
+	}
+}
+
+func TestRunImport_NoMarkedNoActivePath(t *testing.T) {
+	afxMock := &afxtest.MockAFX{}
+
+	// マークもアクティブパスもない場合はデータベースを変更せずに正常終了する
+	if err := runImport(t.Context(), afxMock, io.Discard, testImportOptions(t, true)); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+}
+
+func TestRunImport(t *testing.T) {
+	opts := testImportOptions(t, false)
+	visited := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	if _, err := history.Update(opts.historyPath, func(s *history.Store) {
+		s.Visit(`C:\Projects`, visited.Add(-time.Hour))
+		s.Visit(`C:\Projects`, visited)
+		s.Visit(`C:\Projects`, visited)
+	}); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+	if err := zoxide.WriteDB(opts.dbPath, []zoxide.Dir{
+		{Path: `C:\Projects`, Rank: 2, LastAccessed: visited.Add(-24 * time.Hour)},
+	}); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	afxMock := &afxtest.MockAFX{HistoriesResult: []string{`C:\Projects`, `C:\Users\Test`, `c:\projects\`}}
+	if err := runImport(t.Context(), afxMock, io.Discard, opts); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	dirs, err := zoxide.ReadDB(opts.dbPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(dirs) != 2 {
+		t.Fatalf("2件が期待されましたが、%+v でした", dirs)
+	}
+	// 記録された移動回数と日時で取り込み、既存のランクに足し合わせる
+	if dirs[0].Rank != 5 || !dirs[0].LastAccessed.Equal(visited) {
+		t.Errorf("C:\\Projects のランクまたは日時が正しくありません: %+v", dirs[0])
+	}
+	// 記録のないディレクトリは履歴での位置（3件中2番目）の重みで取り込む
+	if dirs[1].Path != `C:\Users\Test` || dirs[1].Rank != 2.0/3 {
+		t.Errorf("C:\\Users\\Test が正しく取り込まれていません: %+v", dirs[1])
+	}
+}
+
+func TestRunImport_Marked(t *testing.T) {
+	opts := testImportOptions(t, true)
+	afxMock := &afxtest.MockAFX{ExtractResults: map[string]string{afx.MacroActivePath: `C:\Work`}}
+
+	for range 2 {
+		if err := runImport(t.Context(), afxMock, io.Discard, opts); err != nil {
+			t.Fatalf("予期しないエラー: %v", err)
+		}
+	}
+
+	dirs, err := zoxide.ReadDB(opts.dbPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(dirs) != 1 || dirs[0].Rank != 2 {
+		t.Errorf("マークしたディレクトリやアクティブパスは移動1回として記録されるべきです: %+v", dirs)
+	}
+}
+
+func TestRunImport_Incremental(t *testing.T) {
+	opts := testImportOptions(t, false)
+	visited := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	visit := func(at time.Time) {
+		t.Helper()
+		if _, err := history.Update(opts.historyPath, func(s *history.Store) {
+			s.Visit(`C:\Projects`, at)
+		}); err != nil {
+			t.Fatalf("テストファイル作成に失敗しました: %v", err)
+		}
+	}
+	afxMock := &afxtest.MockAFX{HistoriesByWin: map[int][]string{
+		afx.WindowLeft: {`C:\Projects`, `C:\Users\Test`},
+	}}
+
+	visit(visited)
+	visit(visited)
+	if err := runImport(t.Context(), afxMock, io.Discard, opts); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	// 2回目は前回以降の移動1回だけを取り込み、記録のない C:\Users\Test は取り込まない
+	visit(visited.Add(time.Hour))
+	if err := runImport(t.Context(), afxMock, io.Discard, opts); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	// 3回目は新しい移動がないため何も取り込まない
+	if err := runImport(t.Context(), afxMock, io.Discard, opts); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	dirs, err := zoxide.ReadDB(opts.dbPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(dirs) != 2 {
+		t.Fatalf("2件が期待されましたが、%+v でした", dirs)
+	}
+	if dirs[0].Rank != 3 || !dirs[0].LastAccessed.Equal(visited.Add(time.Hour)) {
+		t.Errorf("C:\\Projects のランクまたは日時が正しくありません: %+v", dirs[0])
+	}
+	if dirs[1].Path != `C:\Users\Test` || dirs[1].Rank != 0.5 {
+		t.Errorf("C:\\Users\\Test が1回だけ取り込まれるべきです: %+v", dirs[1])
+	}
+}
+
+func TestRunImport_Recording(t *testing.T) {
+	t.Setenv(zoxide.EnvExcludeDirs, "")
+	opts := testImportOptions(t, false)
+	opts.recording = true
+	visited := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	// [zoxide] record = true の間は移動のたびに履歴とzoxideの両方に記録する
+	visit := func(at time.Time) {
+		t.Helper()
+		if _, err := history.Update(opts.historyPath, func(s *history.Store) {
+			s.Visit(`C:\Projects`, at)
+		}); err != nil {
+			t.Fatalf("テストファイル作成に失敗しました: %v", err)
+		}
+		if !opts.recording {
+			return
+		}
+		if err := zoxide.Record(opts.dbPath, `C:\Projects`, at); err != nil {
+			t.Fatalf("テストファイル作成に失敗しました: %v", err)
+		}
+	}
+	afxMock := &afxtest.MockAFX{HistoriesByWin: map[int][]string{
+		afx.WindowLeft: {`C:\Projects`, `C:\Users\Test`},
+	}}
+
+	visit(visited)
+	visit(visited)
+	var buf bytes.Buffer
+	if err := runImport(t.Context(), afxMock, &buf, opts); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if !strings.Contains(buf.String(), "zoxideに記録済み 1件") {
+		t.Errorf("記録済みの件数が表示されていません: %q", buf.String())
+	}
+	// 記録を無効にした後は、無効にしてからの移動だけを取り込む
+	opts.recording = false
+	visit(visited.Add(time.Hour))
+	if err := runImport(t.Context(), afxMock, io.Discard, opts); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	dirs, err := zoxide.ReadDB(opts.dbPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(dirs) != 2 {
+		t.Fatalf("2件が期待されましたが、%+v でした", dirs)
+	}
+	if dirs[0].Path != `C:\Projects` || dirs[0].Rank != 3 {
+		t.Errorf("C:\\Projects の移動が重複して数えられています: %+v", dirs[0])
+	}
+	if dirs[1].Path != `C:\Users\Test` || dirs[1].Rank != 0.5 {
+		t.Errorf("C:\\Users\\Test が1回だけ取り込まれるべきです: %+v", dirs[1])
+	}
+}
+
+func TestRunImport_DryRun(t *testing.T) {
+	opts := testImportOptions(t, false)
+	opts.dryRun = true
+	afxMock := &afxtest.MockAFX{HistoriesByWin: map[int][]string{
+		afx.WindowLeft:  {`C:\Projects`, `C:\Users\Test`},
+		afx.WindowRight: {`D:\Data`},
+	}}
+
+	var buf bytes.Buffer
+	if err := runImport(t.Context(), afxMock, &buf, opts); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	for _, want := range []string{"+1.00  C:\\Projects\n", "+0.50  C:\\Users\\Test\n", "+1.00  D:\\Data\n", "3件"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("出力に %q が含まれていません: %q", want, buf.String())
+		}
+	}
+	// データベースもインポート済みの記録も作成しない
+	for _, path := range []string{opts.dbPath, opts.statePath} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s が作成されています", path)
+		}
+	}
+}
+
+func TestRunImport_Exclude(t *testing.T) {
+	t.Setenv(zoxide.EnvExcludeDirs, `C:\Temp\*;C:\Windows`)
+	opts := testImportOptions(t, false)
+	afxMock := &afxtest.MockAFX{HistoriesByWin: map[int][]string{
+		afx.WindowLeft: {`C:\Temp\build`, `C:\Projects`, `C:\Windows\`, `C:\Windows\System32`},
+	}}
+
+	if err := runImport(t.Context(), afxMock, io.Discard, opts); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	dirs, err := zoxide.ReadDB(opts.dbPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	got := zoxide.Paths(zoxide.Rank(dirs, time.Now(), func(string) bool { return true }))
+	expected := []string{`C:\Projects`, `C:\Windows\System32`}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, got)
+	}
+}
+
+func TestHistoryRing(t *testing.T) {
+	afxMock := &afxtest.MockAFX{HistoriesByWin: map[int][]string{
+		afx.WindowLeft:  {`C:\A`, `C:\B\`, `C:\C`, `C:\D`},
+		afx.WindowRight: {`c:\c`, `E:\E`},
+	}}
+
+	ring, err := historyRing(t.Context(), afxMock)
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	// 窓ごとに先頭が 1、末尾が 1/件数 で、両方の窓にあるディレクトリは大きい方の重みを使う
+	expected := []ringDir{
+		{path: `C:\A`, weight: 1},
+		{path: `C:\B`, weight: 0.75},
+		{path: `C:\C`, weight: 1},
+		{path: `C:\D`, weight: 0.25},
+		{path: `E:\E`, weight: 0.5},
+	}
+	if !reflect.DeepEqual(ring, expected) {
+		t.Errorf("期待: %+v, 取得: %+v", expected, ring)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/finder"
+	"github.com/tana9/afxw-tools/internal/zoxide"
+)
+
+// runKeywords はターミナルの z foo bar と同じく、keywords に一致するディレクトリへ移動します。
+// スコアが最も高いものが1つに決まればファインダーを開かずに移動し、
+// 同じスコアで並んだ場合だけ一致したディレクトリをファインダーに表示します。
+func runKeywords(ctx context.Context, a afx.AFX, f finder.Finder, query func() ([]zoxide.Entry, error), keywords []string, opposite bool) error {
+	entries, err := query()
+	if err != nil {
+		return fmt.Errorf("zoxideデータベースの取得に失敗しました: %w", err)
+	}
+
+	matched := zoxide.Filter(entries, keywords)
+	if len(matched) == 0 {
+		return fmt.Errorf("%q に一致するディレクトリが見つかりません", strings.Join(keywords, " "))
+	}
+
+	idx := 0
+	if len(matched) > 1 && matched[0].Score == matched[1].Score {
+		idx, err = finder.FindWithOptions(f, zoxide.Paths(matched), finder.Options{
+			Header: fmt.Sprintf("%q に一致するディレクトリが複数あります", strings.Join(keywords, " ")),
+		})
+		if err != nil {
+			// ESCやCtrl+Cでキャンセルされた場合は正常終了
+			if errors.Is(err, fuzzyfinder.ErrAbort) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	if err := afx.Jump(ctx, a, matched[idx].Path, opposite); err != nil {
+		return fmt.Errorf("ディレクトリ移動に失敗しました: %w", err)
+	}
+	return nil
+}
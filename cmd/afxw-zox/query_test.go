@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+	"github.com/tana9/afxw-tools/internal/zoxide"
+)
+
+func TestRunKeywords(t *testing.T) {
+	entries := []zoxide.Entry{
+		{Path: `C:\Users\Test\Projects\afxw`, Score: 40},
+		{Path: `C:\code\afxw-tools`, Score: 20},
+		{Path: `C:\old\afxw-tools`, Score: 20},
+		{Path: `C:\Users\Test\Documents`, Score: 10},
+	}
+
+	tests := []struct {
+		name          string
+		keywords      []string
+		finder        *afxtest.MockFinder
+		expectedPath  string
+		expectedItems []string
+		wantErr       bool
+	}{
+		{
+			name:         "最もスコアの高いものへ移動",
+			keywords:     []string{"afxw"},
+			finder:       &afxtest.MockFinder{},
+			expectedPath: `C:\Users\Test\Projects\afxw`,
+		},
+		{
+			name:         "一致が1件",
+			keywords:     []string{"test", "doc"},
+			finder:       &afxtest.MockFinder{},
+			expectedPath: `C:\Users\Test\Documents`,
+		},
+		{
+			name:          "同じスコアで並んだらファインダーで選ぶ",
+			keywords:      []string{"tools"},
+			finder:        &afxtest.MockFinder{Idx: 1},
+			expectedPath:  `C:\old\afxw-tools`,
+			expectedItems: []string{`C:\code\afxw-tools`, `C:\old\afxw-tools`},
+		},
+		{
+			name:          "キャンセル",
+			keywords:      []string{"tools"},
+			finder:        &afxtest.MockFinder{Err: fuzzyfinder.ErrAbort},
+			expectedItems: []string{`C:\code\afxw-tools`, `C:\old\afxw-tools`},
+		},
+		{
+			name:     "一致しない",
+			keywords: []string{"none"},
+			finder:   &afxtest.MockFinder{},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			afxMock := &afxtest.MockAFX{}
+			err := runKeywords(t.Context(), afxMock, tt.finder, makeQuery(entries, nil), tt.keywords, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("エラー 期待: %v, 取得: %v", tt.wantErr, err)
+			}
+			if afxMock.ExcdPath != tt.expectedPath {
+				t.Errorf("期待: %q, 取得: %q", tt.expectedPath, afxMock.ExcdPath)
+			}
+			if !reflect.DeepEqual(tt.finder.Items, tt.expectedItems) {
+				t.Errorf("ファインダーの候補 期待: %q, 取得: %q", tt.expectedItems, tt.finder.Items)
+			}
+		})
+	}
+}
+
+func TestRunKeywords_QueryError(t *testing.T) {
+	query := makeQuery(nil, errors.New("query error"))
+	if err := runKeywords(t.Context(), &afxtest.MockAFX{}, &afxtest.MockFinder{}, query, []string{"foo"}, false); err == nil {
+		t.Error("エラーが期待されました")
+	}
+}
+
+func TestRunKeywords_Opposite(t *testing.T) {
+	afxMock := &afxtest.MockAFX{}
+	query := makeQuery([]zoxide.Entry{{Path: `C:\code`, Score: 1}}, nil)
+	if err := runKeywords(t.Context(), afxMock, &afxtest.MockFinder{}, query, []string{"code"}, true); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if afxMock.ExcdOppositePath != `C:\code` {
+		t.Errorf("期待: C:\\code, 取得: %s", afxMock.ExcdOppositePath)
+	}
+}
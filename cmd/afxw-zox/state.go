@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/tana9/afxw-tools/internal/fileutil"
+	"github.com/tana9/afxw-tools/internal/winpath"
+)
+
+// importStateFileName はインポート済みの履歴を記録するファイルの名前です。
+const importStateFileName = "zoxide-import.json"
+
+// importedDir はzoxideにインポート済みのディレクトリです。
+type importedDir struct {
+	Path     string    `json:"path"`
+	Count    int       `json:"count"`    // インポート済みの afxw-tools が記録した移動回数
+	Imported time.Time `json:"imported"` // 最後にインポートした日時
+}
+
+// importState はインポート済みのディレクトリを winpath.Key で引けるようにしたものです。
+type importState map[string]importedDir
+
+// stateFile はインポート済みの記録ファイルの形式です。
+type stateFile struct {
+	Dirs []importedDir `json:"dirs"`
+}
+
+// defaultStatePath はインポート済みの記録ファイルのパスを返します。
+// Windows では %APPDATA%\afxw-tools\zoxide-import.json になります。
+func defaultStatePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "afxw-tools", importStateFileName), nil
+}
+
+// loadImportState は path からインポート済みの記録を読み込みます。
+// ファイルが存在しない場合は空の記録を返します。
+func loadImportState(path string) (importState, error) {
+	state := make(importState)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("インポート済みの記録の読み込みに失敗しました: %w", err)
+	}
+
+	var f stateFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("インポート済みの記録の解析に失敗しました: %w", err)
+	}
+	for _, d := range f.Dirs {
+		state[winpath.Key(d.Path)] = d
+	}
+	return state, nil
+}
+
+// save はインポート済みの記録を path に書き込みます。
+func (s importState) save(path string) error {
+	f := stateFile{Dirs: make([]importedDir, 0, len(s))}
+	for _, d := range s {
+		f.Dirs = append(f.Dirs, d)
+	}
+	sort.Slice(f.Dirs, func(i, j int) bool {
+		return winpath.Key(f.Dirs[i].Path) < winpath.Key(f.Dirs[j].Path)
+	})
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("インポート済みの記録のエンコードに失敗しました: %w", err)
+	}
+	return fileutil.WriteAtomic(path, data)
+}
@@ -1,12 +1,15 @@
 package afx
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"runtime"
+	"os"
 	"strings"
+	"time"
 
-	"github.com/go-ole/go-ole"
-	"github.com/go-ole/go-ole/oleutil"
+	"github.com/tana9/afxw-tools/internal/afx/cmd"
+	"github.com/tana9/afxw-tools/internal/winpath"
 )
 
 const (
@@ -17,61 +20,91 @@ const (
 )
 
 // AFX は afxw.obj と対話するためのインターフェースを提供します。
+// 各メソッドは ctx の期限が切れるかキャンセルされると、あふwの応答を待たずに戻ります。
 type AFX interface {
-	Histories(wins []int) ([]string, error)
-	EXCD(path string) error
-	GetActivePath() (string, error)
+	Histories(ctx context.Context, wins []int) ([]string, error)
+	EXCD(ctx context.Context, path string) error
+	EXCDIn(ctx context.Context, win int, path string) error
+	EXCDOpposite(ctx context.Context, path string) error
+	Swap(ctx context.Context) error
+	Cursor(ctx context.Context, name string) error
+	Extract(ctx context.Context, macro string) (string, error)
+	GetActivePath(ctx context.Context) (string, error)
+	PathOf(ctx context.Context, win int) (string, error)
+	MarkedFiles(ctx context.Context) ([]string, error)
 	Close()
 }
 
-type oleAFX struct {
-	afxw    *ole.IDispatch
-	unknown *ole.IUnknown
+// backend は afxw.obj が公開するメソッドを表します。
+// AFX の各操作はこれらの組み合わせで実装されるため、COM と JSON-RPC で同じ振る舞いになります。
+type backend interface {
+	HisDirCount(ctx context.Context, win int) (int, error)
+	HisDir(ctx context.Context, win, idx int) (string, error)
+	Exec(ctx context.Context, command string) error
+	Extract(ctx context.Context, macro string) (string, error)
+	Close()
 }
 
-// NewOleAFX は実際の afxw.obj と対話する新しい AFX インスタンスを作成します。
-func NewOleAFX() (AFX, error) {
-	runtime.LockOSThread()
-	success := false
-	defer func() {
-		if !success {
-			runtime.UnlockOSThread()
-		}
-	}()
+// DefaultTimeout は1回の操作であふwの応答を待つ既定の時間です。
+// ctx に期限が設定されている場合はそちらが優先されます。
+const DefaultTimeout = 5 * time.Second
 
-	if err := ole.CoInitialize(0); err != nil {
-		return nil, fmt.Errorf("COMの初期化に失敗しました: %w", err)
-	}
-	defer func() {
-		if !success {
-			ole.CoUninitialize()
-		}
-	}()
+// ErrTimeout はあふwが期限内に応答しなかったことを示します。
+var ErrTimeout = errors.New("あふwが応答しません（タイムアウト）")
 
-	unknown, err := oleutil.CreateObject("afxw.obj")
-	if err != nil {
-		return nil, fmt.Errorf("afxw.objの作成に失敗しました: %w", err)
-	}
-	defer func() {
-		if !success {
-			unknown.Release()
+// ErrAmbiguousWindow は左右の窓が同じディレクトリを表示しているため、アクティブウィンドウを判定できないことを示します。
+var ErrAmbiguousWindow = errors.New("左右の窓が同じディレクトリを表示しているため、アクティブウィンドウを判定できません")
+
+// client は backend を使って AFX を実装します。
+type client struct {
+	b       backend
+	timeout time.Duration
+}
+
+// New は環境に応じた AFX インスタンスを作成します。
+// 環境変数 AFXW_RPC が設定されている場合は JSON-RPC ブリッジに、それ以外は afxw.obj に接続します。
+// ctx に期限が設定されていなければ、接続は DefaultTimeout で打ち切ります。
+func New(ctx context.Context) (AFX, error) {
+	if target := os.Getenv(EnvRPC); target != "" {
+		network, address, err := parseRPCTarget(target)
+		if err != nil {
+			return nil, err
 		}
-	}()
+		return DialRPC(ctx, network, address)
+	}
+	return NewOleAFX(ctx)
+}
 
-	afxw, err := unknown.QueryInterface(ole.IID_IDispatch)
-	if err != nil {
-		return nil, fmt.Errorf("IDispatchの取得に失敗しました: %w", err)
+// withTimeout は ctx に期限が設定されていなければ既定のタイムアウトを設定します。
+func (c *client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withDefaultTimeout(ctx, c.timeout)
+}
+
+// withDefaultTimeout は ctx に期限が設定されていなければ timeout の期限を設定します。
+// timeout が 0 以下の場合は期限を設定しません。
+func withDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, timeout)
+}
 
-	success = true
-	return &oleAFX{afxw: afxw, unknown: unknown}, nil
+// ctxErr は ctx の終了理由をエラーに変換します。期限切れの場合は ErrTimeout を返します。
+func ctxErr(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())
+	}
+	return ctx.Err()
 }
 
 // Histories は指定されたウィンドウの履歴ディレクトリを取得します。
-func (a *oleAFX) Histories(wins []int) ([]string, error) {
+func (c *client) Histories(ctx context.Context, wins []int) ([]string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
 	var dirs []string
 	for _, win := range wins {
-		winDirs, err := a.getWindowHistories(win)
+		winDirs, err := c.getWindowHistories(ctx, win)
 		if err != nil {
 			return nil, err
 		}
@@ -81,61 +114,190 @@ func (a *oleAFX) Histories(wins []int) ([]string, error) {
 }
 
 // getWindowHistories は指定されたウィンドウの履歴ディレクトリ一覧を取得します。
-func (a *oleAFX) getWindowHistories(win int) ([]string, error) {
-	res, err := oleutil.CallMethod(a.afxw, "HisDirCount", win)
+func (c *client) getWindowHistories(ctx context.Context, win int) ([]string, error) {
+	count, err := c.b.HisDirCount(ctx, win)
 	if err != nil {
 		return nil, fmt.Errorf("履歴件数の取得に失敗しました: %w", err)
 	}
-	count := res.Value().(int32)
-	res.Clear()
 
 	dirs := make([]string, 0, count)
-	for i := 0; i < int(count); i++ {
-		res, err := oleutil.CallMethod(a.afxw, "HisDir", win, i)
+	for i := 0; i < count; i++ {
+		dir, err := c.b.HisDir(ctx, win, i)
 		if err != nil {
 			return nil, fmt.Errorf("履歴の取得に失敗しました: %w", err)
 		}
-		dirs = append(dirs, fmt.Sprint(res.Value()))
-		res.Clear()
+		dirs = append(dirs, dir)
 	}
 	return dirs, nil
 }
 
 // EXCD は指定されたパスにディレクトリを変更します。
-func (a *oleAFX) EXCD(path string) error {
+func (c *client) EXCD(ctx context.Context, path string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.b.Exec(ctx, cmd.EXCD(ensureTrailingBackslash(path)).String()); err != nil {
+		return fmt.Errorf("EXCD呼び出しに失敗しました: %w", err)
+	}
+	return nil
+}
+
+// EXCDIn は指定されたウィンドウのディレクトリを変更します。
+// 左右の窓が同じディレクトリを表示していてアクティブウィンドウを判定できない場合は、
+// アクティブウィンドウを移動してからどちらの窓が移動したかを確かめ、反対の窓が移動していれば
+// 元のディレクトリに戻してから win の窓を移動します。
+func (c *client) EXCDIn(ctx context.Context, win int, path string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 
-	normalizedPath := ensureTrailingBackslash(path)
+	active, err := c.activeWindow(ctx)
+	if errors.Is(err, ErrAmbiguousWindow) {
+		return c.excdAmbiguous(ctx, win, path)
+	}
+	if err != nil {
+		return err
+	}
+	if win == active {
+		return c.EXCD(ctx, path)
+	}
+	return c.EXCDOpposite(ctx, path)
+}
+
+// excdAmbiguous は左右の窓が同じディレクトリを表示している状態で win の窓を path へ移動します。
+func (c *client) excdAmbiguous(ctx context.Context, win int, path string) error {
+	current, err := c.PathOf(ctx, win)
+	if err != nil {
+		return err
+	}
+	if winpath.Equal(current, path) {
+		return nil
+	}
 
-	_, err := oleutil.CallMethod(a.afxw, "Exec", fmt.Sprintf("&EXCD -P\"%s\"", normalizedPath))
+	if err := c.EXCD(ctx, path); err != nil {
+		return err
+	}
+	other, err := c.PathOf(ctx, 1-win)
 	if err != nil {
+		return err
+	}
+	if winpath.Equal(other, current) {
+		// 反対の窓が元のままなので、アクティブウィンドウは win だった
+		return nil
+	}
+	if err := c.EXCD(ctx, current); err != nil {
+		return fmt.Errorf("誤って移動した窓を元に戻せませんでした: %w", err)
+	}
+	return c.EXCDOpposite(ctx, path)
+}
+
+// EXCDOpposite は反対窓のディレクトリを変更します。
+func (c *client) EXCDOpposite(ctx context.Context, path string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.b.Exec(ctx, cmd.EXCDOpposite(ensureTrailingBackslash(path)).String()); err != nil {
 		return fmt.Errorf("EXCD呼び出しに失敗しました: %w", err)
 	}
 	return nil
 }
 
+// Swap は左右のウィンドウのディレクトリを入れ替えます。
+func (c *client) Swap(ctx context.Context) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.b.Exec(ctx, cmd.Swap().String()); err != nil {
+		return fmt.Errorf("左右の入れ替えに失敗しました: %w", err)
+	}
+	return nil
+}
+
+// Cursor はアクティブウィンドウのカーソルを name のファイルへ移動します。
+// name はカレントディレクトリにあるファイルの名前です。
+func (c *client) Cursor(ctx context.Context, name string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.b.Exec(ctx, cmd.Cursor(name).String()); err != nil {
+		return fmt.Errorf("カーソルの移動に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// Extract はあふwのマクロを展開した結果を返します。
+func (c *client) Extract(ctx context.Context, macro string) (string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	value, err := c.b.Extract(ctx, macro)
+	if err != nil {
+		return "", fmt.Errorf("マクロの展開に失敗しました (%s): %w", macro, err)
+	}
+	return value, nil
+}
+
 // GetActivePath はアクティブウィンドウのカレントディレクトリを取得します。
-func (a *oleAFX) GetActivePath() (string, error) {
-	// $P はアクティブウィンドウのカレントディレクトリに展開されます
-	res, err := oleutil.CallMethod(a.afxw, "Extract", "$P")
+func (c *client) GetActivePath(ctx context.Context) (string, error) {
+	path, err := c.Extract(ctx, MacroActivePath)
 	if err != nil {
 		return "", fmt.Errorf("アクティブパスの取得に失敗しました: %w", err)
 	}
-	path := fmt.Sprint(res.Value())
-	res.Clear()
 	return path, nil
 }
 
-// Close はCOMリソースを解放し、OSスレッドのロックを解除します。
-func (a *oleAFX) Close() {
-	defer runtime.UnlockOSThread()
-	defer ole.CoUninitialize()
+// PathOf は指定されたウィンドウのカレントディレクトリを取得します。
+// あふwの履歴は先頭がカレントディレクトリなので、HisDir の0番目を返します。
+func (c *client) PathOf(ctx context.Context, win int) (string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 
-	if a.afxw != nil {
-		a.afxw.Release()
+	path, err := c.b.HisDir(ctx, win, 0)
+	if err != nil {
+		return "", fmt.Errorf("ウィンドウのパス取得に失敗しました: %w", err)
 	}
-	if a.unknown != nil {
-		a.unknown.Release()
+	return path, nil
+}
+
+// MarkedFiles はアクティブウィンドウでマークされたファイルのフルパス一覧を取得します。
+func (c *client) MarkedFiles(ctx context.Context) ([]string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	return markedFiles(ctx, c)
+}
+
+// activeWindow はアクティブウィンドウの番号を返します。
+func (c *client) activeWindow(ctx context.Context) (int, error) {
+	return ActiveWindow(ctx, c)
+}
+
+// ActiveWindow はアクティブウィンドウの番号を返します。
+// 左右の窓が同じディレクトリを表示している場合はどちらがアクティブか判定できないため、ErrAmbiguousWindow を返します。
+func ActiveWindow(ctx context.Context, a AFX) (int, error) {
+	active, err := a.GetActivePath(ctx)
+	if err != nil {
+		return 0, err
 	}
+	left, err := a.PathOf(ctx, WindowLeft)
+	if err != nil {
+		return 0, err
+	}
+	if !winpath.Equal(active, left) {
+		return WindowRight, nil
+	}
+	right, err := a.PathOf(ctx, WindowRight)
+	if err != nil {
+		return 0, err
+	}
+	if winpath.Equal(left, right) {
+		return 0, ErrAmbiguousWindow
+	}
+	return WindowLeft, nil
+}
+
+// Close は接続を閉じます。
+func (c *client) Close() {
+	c.b.Close()
 }
 
 // ensureTrailingBackslash は指定されたパスの末尾にバックスラッシュを追加します（既にある場合は追加しません）。
@@ -145,3 +307,38 @@ func ensureTrailingBackslash(path string) string {
 	}
 	return path
 }
+
+// Jump は指定されたパスへ移動します。opposite が true の場合は反対窓を移動します。
+func Jump(ctx context.Context, a AFX, path string, opposite bool) error {
+	if opposite {
+		return a.EXCDOpposite(ctx, path)
+	}
+	return a.EXCD(ctx, path)
+}
+
+// JumpPair は左窓を left へ、右窓を right へ移動します。空のパスを指定した窓は移動しません。
+// 窓ごとに EXCDIn で移動するため、移動のたびにアクティブウィンドウを判定し直します。
+func JumpPair(ctx context.Context, a AFX, left, right string) error {
+	for win, path := range [2]string{left, right} {
+		if path == "" {
+			continue
+		}
+		if err := a.EXCDIn(ctx, win, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JumpToFile は path のファイルがあるディレクトリへ移動し、カーソルをそのファイルに合わせます。
+// opposite が true の場合は反対窓を移動します。
+// あふwのカーソル移動はアクティブウィンドウにだけ作用するため、反対窓ではディレクトリの移動だけを行います。
+func JumpToFile(ctx context.Context, a AFX, path string, opposite bool) error {
+	if err := Jump(ctx, a, winpath.Dir(path), opposite); err != nil {
+		return err
+	}
+	if opposite {
+		return nil
+	}
+	return a.Cursor(ctx, winpath.Base(path))
+}
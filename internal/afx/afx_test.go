@@ -1,7 +1,12 @@
 package afx
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestClose_NilFields(t *testing.T) {
@@ -31,3 +36,85 @@ func TestEnsureTrailingBackslash(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRPCTarget(t *testing.T) {
+	tests := []struct {
+		target          string
+		expectedNetwork string
+		expectedAddress string
+		expectErr       bool
+	}{
+		{"tcp://127.0.0.1:9000", "tcp", "127.0.0.1:9000", false},
+		{"unix:///tmp/afxw.sock", "unix", "/tmp/afxw.sock", false},
+		{"udp://127.0.0.1:9000", "", "", true},
+		{"127.0.0.1:9000", "", "", true},
+		{"tcp://", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			network, address, err := parseRPCTarget(tt.target)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("エラーが期待されましたが、nilが返りました")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if network != tt.expectedNetwork || address != tt.expectedAddress {
+				t.Errorf("期待: %s %s, 取得: %s %s", tt.expectedNetwork, tt.expectedAddress, network, address)
+			}
+		})
+	}
+}
+
+func TestWithDefaultTimeout(t *testing.T) {
+	// 期限のない ctx には timeout の期限を設定する
+	ctx, cancel := withDefaultTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("1分以内の期限が期待されましたが、%v %v でした", deadline, ok)
+	}
+
+	// 呼び出し側の期限はそのまま使う
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Hour)
+	defer cancelParent()
+	ctx, cancel = withDefaultTimeout(parent, time.Minute)
+	defer cancel()
+	if deadline, _ := ctx.Deadline(); time.Until(deadline) < 59*time.Minute {
+		t.Errorf("呼び出し側の期限が使われていません: %v", deadline)
+	}
+
+	// timeout が 0 の場合は期限を設定しない
+	ctx, cancel = withDefaultTimeout(context.Background(), 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("期限が設定されています")
+	}
+}
+
+// stubAFX は EXCD だけを成功させる AFX です。
+type stubAFX struct{ AFX }
+
+func (stubAFX) EXCD(ctx context.Context, path string) error { return nil }
+
+func TestWithJumpHook_HookError(t *testing.T) {
+	var buf bytes.Buffer
+	orig := hookWarnings
+	hookWarnings = &buf
+	t.Cleanup(func() { hookWarnings = orig })
+
+	a := WithJumpHook(stubAFX{}, func(ctx context.Context, path string) error {
+		return errors.New("記録に失敗しました")
+	})
+
+	// フックが失敗しても移動は成功として扱い、警告を表示する
+	if err := a.EXCD(t.Context(), `C:\A`); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if got := buf.String(); !strings.Contains(got, "警告: 記録に失敗しました") {
+		t.Errorf("警告が表示されていません: %q", got)
+	}
+}
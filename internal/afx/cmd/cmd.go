@@ -0,0 +1,196 @@
+// Package cmd はあふwの内部コマンド文字列を組み立てます。
+//
+// あふwの Exec に渡す文字列では $ がマクロの開始文字として扱われるため、
+// パスなどの値は必ずこのパッケージを通してエスケープしてください。
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Command はあふwの内部コマンドを表します。
+type Command struct {
+	name string
+	args []string
+}
+
+// New は指定された名前の内部コマンドを作成します。
+// name には先頭の & を含めません（例: "EXCD"）。
+func New(name string) *Command {
+	return &Command{name: name}
+}
+
+// Opt は -<flag>"<value>" 形式のオプションを追加します。value はエスケープされます。
+func (c *Command) Opt(flag, value string) *Command {
+	c.args = append(c.args, "-"+flag+Quote(value))
+	return c
+}
+
+// OptMacro は -<flag>"<macro>" 形式のオプションを追加します。
+// macro はエスケープされないため、$P などのマクロをそのまま渡せます。
+func (c *Command) OptMacro(flag, macro string) *Command {
+	c.args = append(c.args, "-"+flag+`"`+macro+`"`)
+	return c
+}
+
+// Arg は "<value>" 形式の引数を追加します。value はエスケープされます。
+func (c *Command) Arg(value string) *Command {
+	c.args = append(c.args, Quote(value))
+	return c
+}
+
+// String はあふwの Exec に渡すコマンド文字列を返します。
+func (c *Command) String() string {
+	if len(c.args) == 0 {
+		return "&" + c.name
+	}
+	return "&" + c.name + " " + strings.Join(c.args, " ")
+}
+
+// escaper はマクロ文字とダブルクォートを二重化します。
+var escaper = strings.NewReplacer(`$`, `$$`, `"`, `""`)
+
+// Escape は文字列をあふwのコマンド引数として扱えるようにエスケープします。
+// $ は $$ に、" は "" に置き換えます。
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// Quote は文字列をエスケープしてダブルクォートで囲みます。
+func Quote(s string) string {
+	return `"` + Escape(s) + `"`
+}
+
+// EXCD はアクティブウィンドウのディレクトリを変更するコマンドを返します。
+func EXCD(path string) *Command {
+	return New("EXCD").Opt("P", path)
+}
+
+// EXCDOpposite は反対窓のディレクトリを変更するコマンドを返します。
+func EXCDOpposite(path string) *Command {
+	return New("EXCD").Opt("O", path)
+}
+
+// Swap は左右のウィンドウのディレクトリを入れ替えるコマンドを返します。
+// $P と $O は Exec 時に展開されるため、1回の EXCD で入れ替えられます。
+func Swap() *Command {
+	return New("EXCD").OptMacro("P", "$O").OptMacro("O", "$P")
+}
+
+// Menu は指定されたメニューファイルを開くコマンドを返します。
+func Menu(path string) *Command {
+	return New("MENU").Arg(path)
+}
+
+// Cursor はカーソルを指定されたファイルへ移動するコマンドを返します。
+func Cursor(name string) *Command {
+	return New("CURSOR").Arg(name)
+}
+
+// Jump は指定されたジャンプリストファイルを開くコマンドを返します。
+func Jump(path string) *Command {
+	return New("JUMP").Arg(path)
+}
+
+// Arg は Parse で分解された引数です。
+type Arg struct {
+	Flag  string // オプション名（-P なら "P"）。位置引数の場合は空です
+	Value string // クォートを外した値。$ のエスケープはそのまま残ります
+}
+
+// Parse は内部コマンド文字列を名前と引数に分解します。
+// 値の "" は " に戻しますが、$ のエスケープとマクロは Expand で展開してください。
+func Parse(s string) (string, []Arg, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "&") {
+		return "", nil, fmt.Errorf("内部コマンドは & で始まる必要があります: %s", s)
+	}
+	name, rest, _ := strings.Cut(s[1:], " ")
+	if name == "" {
+		return "", nil, fmt.Errorf("コマンド名がありません: %s", s)
+	}
+
+	var args []Arg
+	for i := 0; i < len(rest); {
+		if rest[i] == ' ' {
+			i++
+			continue
+		}
+
+		var arg Arg
+		if rest[i] == '-' {
+			j := i + 1
+			for j < len(rest) && isFlagChar(rest[j]) {
+				j++
+			}
+			arg.Flag = rest[i+1 : j]
+			i = j
+		}
+
+		if i < len(rest) && rest[i] == '"' {
+			value, n, err := unquote(rest[i:])
+			if err != nil {
+				return "", nil, err
+			}
+			arg.Value = value
+			i += n
+		} else {
+			j := i
+			for j < len(rest) && rest[j] != ' ' {
+				j++
+			}
+			arg.Value = rest[i:j]
+			i = j
+		}
+		args = append(args, arg)
+	}
+	return name, args, nil
+}
+
+// unquote は先頭のダブルクォートで囲まれた値を取り出し、消費したバイト数とともに返します。
+func unquote(s string) (string, int, error) {
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != '"' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '"' {
+			sb.WriteByte('"')
+			i++
+			continue
+		}
+		return sb.String(), i + 1, nil
+	}
+	return "", 0, fmt.Errorf("ダブルクォートが閉じられていません: %s", s)
+}
+
+// isFlagChar はオプション名に使える文字かを返します。
+func isFlagChar(c byte) bool {
+	return ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z')
+}
+
+// Expand は値に含まれるマクロを展開し、$ のエスケープを元に戻します。
+// $$ は $ に、macros に含まれる $X はその値に置き換えます。未知のマクロはそのまま残します。
+func Expand(s string, macros map[string]string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		if s[i+1] == '$' {
+			sb.WriteByte('$')
+			i++
+			continue
+		}
+		if value, ok := macros[s[i:i+2]]; ok {
+			sb.WriteString(value)
+			i++
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
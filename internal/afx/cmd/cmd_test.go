@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"通常のパス", `C:\Users\Test`, `C:\Users\Test`},
+		{"日本語のパス", `C:\ユーザー\資料\表示`, `C:\ユーザー\資料\表示`},
+		{"ドル記号", `C:\$Recycle.Bin`, `C:\$$Recycle.Bin`},
+		{"連続したドル記号", `D:\cost$$\2024`, `D:\cost$$$$\2024`},
+		{"マクロに見える文字列", `C:\work\$P\$O`, `C:\work\$$P\$$O`},
+		{"ダブルクォート", `C:\a "quoted" dir`, `C:\a ""quoted"" dir`},
+		{"記号だらけのパス", `C:\100% & (x86)\#1 ;'!@`, `C:\100% & (x86)\#1 ;'!@`},
+		{"UNCと日本語と記号", `\\サーバー\共有$\部署"A"`, `\\サーバー\共有$$\部署""A""`},
+		{"空文字列", ``, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Escape(tt.input); got != tt.expected {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCommand_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmd      *Command
+		expected string
+	}{
+		{"EXCD", EXCD(`C:\Users\Test\`), `&EXCD -P"C:\Users\Test\"`},
+		{"EXCD 日本語", EXCD(`C:\プロジェクト\見積$\`), `&EXCD -P"C:\プロジェクト\見積$$\"`},
+		{"EXCD クォート", EXCD(`C:\say "hi"\`), `&EXCD -P"C:\say ""hi""\"`},
+		{"EXCDOpposite", EXCDOpposite(`D:\作業\`), `&EXCD -O"D:\作業\"`},
+		{"Swap", Swap(), `&EXCD -P"$O" -O"$P"`},
+		{"Menu", Menu(`C:\afxw\menu $1.txt`), `&MENU "C:\afxw\menu $$1.txt"`},
+		{"Cursor", Cursor(`報告書 "最終".xlsx`), `&CURSOR "報告書 ""最終"".xlsx"`},
+		{"Jump", Jump(`C:\afxw\jump.txt`), `&JUMP "C:\afxw\jump.txt"`},
+		{"引数なし", New("QUIT"), `&QUIT`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cmd.String(); got != tt.expected {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		expectedName string
+		expectedArgs []Arg
+		expectErr    bool
+	}{
+		{"オプション", `&EXCD -P"C:\Users\"`, "EXCD", []Arg{{Flag: "P", Value: `C:\Users\`}}, false},
+		{"複数オプション", `&EXCD -P"$O" -O"$P"`, "EXCD", []Arg{{Flag: "P", Value: "$O"}, {Flag: "O", Value: "$P"}}, false},
+		{"位置引数", `&MENU "C:\menu 1.txt"`, "MENU", []Arg{{Value: `C:\menu 1.txt`}}, false},
+		{"クォートなし", `&CURSOR file.txt`, "CURSOR", []Arg{{Value: "file.txt"}}, false},
+		{"クォートのエスケープ", `&CURSOR "a ""b"" c"`, "CURSOR", []Arg{{Value: `a "b" c`}}, false},
+		{"引数なし", `&QUIT`, "QUIT", nil, false},
+		{"& なし", `EXCD -P"C:\"`, "", nil, true},
+		{"閉じていないクォート", `&EXCD -P"C:\`, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, args, err := Parse(tt.input)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("エラーが期待されましたが、nilが返りました")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if name != tt.expectedName {
+				t.Errorf("コマンド名 期待: %q, 取得: %q", tt.expectedName, name)
+			}
+			if !reflect.DeepEqual(args, tt.expectedArgs) {
+				t.Errorf("引数 期待: %+v, 取得: %+v", tt.expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestParseExpand_RoundTrip(t *testing.T) {
+	paths := []string{
+		`C:\Users\Test\`,
+		`C:\プロジェクト\見積$\`,
+		`C:\$Recycle.Bin\$P\`,
+		`D:\say "hi" $$ 100%\`,
+		`\\サーバー\共有$\部署"A"\`,
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			_, args, err := Parse(EXCD(path).String())
+			if err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			got := Expand(args[0].Value, map[string]string{"$P": "展開されてはいけない"})
+			if got != path {
+				t.Errorf("期待: %q, 取得: %q", path, got)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	macros := map[string]string{"$P": `C:\Left`, "$O": `D:\右`}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"$P", `C:\Left`},
+		{"$O", `D:\右`},
+		{"$$P", "$P"},
+		{"$$$P", `$C:\Left`},
+		{"$X", "$X"},
+		{"末尾の$", "末尾の$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Expand(tt.input, macros); got != tt.expected {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
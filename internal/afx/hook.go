@@ -0,0 +1,58 @@
+package afx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// JumpHook は EXCD 系の呼び出しが成功した後に、移動先のパスを受け取って呼ばれる関数です。
+// 移動の記録などに使います。
+type JumpHook func(ctx context.Context, path string) error
+
+// hookWarnings は JumpHook が返したエラーの警告を書き込む先です。テストで差し替えます。
+var hookWarnings io.Writer = os.Stderr
+
+// WithJumpHook は EXCD, EXCDIn, EXCDOpposite が成功するたびに hook を呼び出す AFX を返します。
+// それ以外のメソッドは a にそのまま委譲します。
+// 移動自体は成功しているため、hook がエラーを返しても移動は失敗させず、警告を表示するだけにします。
+func WithJumpHook(a AFX, hook JumpHook) AFX {
+	return &hookedAFX{AFX: a, hook: hook}
+}
+
+type hookedAFX struct {
+	AFX
+	hook JumpHook
+}
+
+// jumped は移動が成功した後に hook を呼び出します。
+func (h *hookedAFX) jumped(ctx context.Context, path string) {
+	if err := h.hook(ctx, path); err != nil {
+		fmt.Fprintf(hookWarnings, "警告: %v\n", err)
+	}
+}
+
+func (h *hookedAFX) EXCD(ctx context.Context, path string) error {
+	if err := h.AFX.EXCD(ctx, path); err != nil {
+		return err
+	}
+	h.jumped(ctx, path)
+	return nil
+}
+
+func (h *hookedAFX) EXCDIn(ctx context.Context, win int, path string) error {
+	if err := h.AFX.EXCDIn(ctx, win, path); err != nil {
+		return err
+	}
+	h.jumped(ctx, path)
+	return nil
+}
+
+func (h *hookedAFX) EXCDOpposite(ctx context.Context, path string) error {
+	if err := h.AFX.EXCDOpposite(ctx, path); err != nil {
+		return err
+	}
+	h.jumped(ctx, path)
+	return nil
+}
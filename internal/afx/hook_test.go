@@ -0,0 +1,52 @@
+package afx_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+)
+
+func TestWithJumpHook(t *testing.T) {
+	var jumped []string
+	a := afx.WithJumpHook(&afxtest.MockAFX{}, func(ctx context.Context, path string) error {
+		jumped = append(jumped, path)
+		return nil
+	})
+
+	if err := a.EXCD(t.Context(), `C:\A`); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if err := a.EXCDIn(t.Context(), afx.WindowRight, `C:\B`); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if err := a.EXCDOpposite(t.Context(), `C:\C`); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if err := a.Swap(t.Context()); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	expected := []string{`C:\A`, `C:\B`, `C:\C`}
+	if !reflect.DeepEqual(jumped, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, jumped)
+	}
+}
+
+func TestWithJumpHook_NotCalledOnError(t *testing.T) {
+	called := false
+	a := afx.WithJumpHook(&afxtest.MockAFX{ExcdErr: errors.New("excd error")}, func(ctx context.Context, path string) error {
+		called = true
+		return nil
+	})
+
+	if err := a.EXCD(t.Context(), `C:\A`); err == nil {
+		t.Fatal("エラーが期待されましたが、nilが返りました")
+	}
+	if called {
+		t.Error("EXCD が失敗した場合はフックが呼ばれるべきではありません")
+	}
+}
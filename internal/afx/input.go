@@ -0,0 +1,67 @@
+package afx
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// markedFiles は $M で得たファイル名をアクティブウィンドウのパスと連結して返します。
+func markedFiles(ctx context.Context, a AFX) ([]string, error) {
+	names, err := MarkedFileNames(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return names, nil
+	}
+
+	dir, err := a.GetActivePath(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dir = ensureTrailingBackslash(dir)
+
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = dir + name
+	}
+	return paths, nil
+}
+
+// MarkedDirs はマークされたファイルのうちディレクトリだけを返します。
+func MarkedDirs(ctx context.Context, a AFX) ([]string, error) {
+	files, err := a.MarkedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make([]string, 0, len(files))
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && info.IsDir() {
+			dirs = append(dirs, file)
+		}
+	}
+	return dirs, nil
+}
+
+// TargetDirs はツールの入力とするディレクトリ一覧を返します。
+// マークされたディレクトリがあればそれらを、なければアクティブウィンドウのパスを返します。
+func TargetDirs(ctx context.Context, a AFX) ([]string, error) {
+	dirs, err := MarkedDirs(ctx, a)
+	if err != nil {
+		return nil, fmt.Errorf("マークされたディレクトリの取得に失敗しました: %w", err)
+	}
+	if len(dirs) > 0 {
+		return dirs, nil
+	}
+
+	path, err := a.GetActivePath(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return []string{}, nil
+	}
+	return []string{path}, nil
+}
@@ -0,0 +1,94 @@
+package afx_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+)
+
+func TestMarkedDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	dir1 := filepath.Join(tmpDir, "dir1")
+	dir2 := filepath.Join(tmpDir, "新しい フォルダ")
+	file := filepath.Join(tmpDir, "file.txt")
+	for _, d := range []string{dir1, dir2} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatalf("ディレクトリ作成に失敗しました: %v", err)
+		}
+	}
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("ファイル作成に失敗しました: %v", err)
+	}
+
+	a := &afxtest.MockAFX{
+		MarkedFilesResult: []string{dir1, file, dir2, filepath.Join(tmpDir, "missing")},
+	}
+
+	dirs, err := afx.MarkedDirs(t.Context(), a)
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if !reflect.DeepEqual(dirs, []string{dir1, dir2}) {
+		t.Errorf("期待: %q, 取得: %q", []string{dir1, dir2}, dirs)
+	}
+}
+
+func TestTargetDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		afxMock  *afxtest.MockAFX
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name: "マークされたディレクトリを優先",
+			afxMock: &afxtest.MockAFX{
+				MarkedFilesResult: []string{tmpDir},
+				ExtractResults:    map[string]string{afx.MacroActivePath: `C:\Active`},
+			},
+			expected: []string{tmpDir},
+		},
+		{
+			name: "マークがなければアクティブパス",
+			afxMock: &afxtest.MockAFX{
+				ExtractResults: map[string]string{afx.MacroActivePath: `C:\Active`},
+			},
+			expected: []string{`C:\Active`},
+		},
+		{
+			name:     "どちらもなければ空",
+			afxMock:  &afxtest.MockAFX{},
+			expected: []string{},
+		},
+		{
+			name:    "マークファイルの取得エラー",
+			afxMock: &afxtest.MockAFX{MarkedFilesErr: errors.New("marked error")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirs, err := afx.TargetDirs(t.Context(), tt.afxMock)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("エラーが期待されましたが、nilが返りました")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if !reflect.DeepEqual(dirs, tt.expected) {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, dirs)
+			}
+		})
+	}
+}
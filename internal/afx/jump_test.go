@@ -0,0 +1,45 @@
+package afx_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+)
+
+func TestJumpToFile_Mock(t *testing.T) {
+	tests := []struct {
+		name             string
+		opposite         bool
+		expectedPath     string
+		expectedOpposite string
+		expectedCursor   string
+	}{
+		{"アクティブ窓", false, `C:\Docs`, "", "report.xlsx"},
+		{"反対窓", true, "", `C:\Docs`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &afxtest.MockAFX{}
+			if err := afx.JumpToFile(t.Context(), a, `C:\Docs\report.xlsx`, tt.opposite); err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if a.ExcdPath != tt.expectedPath || a.ExcdOppositePath != tt.expectedOpposite || a.CursorName != tt.expectedCursor {
+				t.Errorf("期待: %q %q %q, 取得: %q %q %q",
+					tt.expectedPath, tt.expectedOpposite, tt.expectedCursor, a.ExcdPath, a.ExcdOppositePath, a.CursorName)
+			}
+		})
+	}
+}
+
+func TestJumpToFile_ExcdError(t *testing.T) {
+	a := &afxtest.MockAFX{ExcdErr: errors.New("excd error")}
+	if err := afx.JumpToFile(t.Context(), a, `C:\Docs\report.xlsx`, false); err == nil {
+		t.Error("エラーが期待されましたが、nilが返りました")
+	}
+	if a.CursorName != "" {
+		t.Errorf("移動に失敗した場合はカーソルを移動するべきではありません: %q", a.CursorName)
+	}
+}
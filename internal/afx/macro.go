@@ -0,0 +1,110 @@
+package afx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tana9/afxw-tools/internal/winpath"
+)
+
+// あふwのマクロです。Extract に渡すと展開された文字列が得られます。
+const (
+	// MacroActivePath はアクティブウィンドウのカレントディレクトリです。
+	MacroActivePath = "$P"
+	// MacroOppositePath は反対窓のカレントディレクトリです。
+	MacroOppositePath = "$O"
+	// MacroCursorFile はカーソル位置のファイル名です。
+	MacroCursorFile = "$F"
+	// MacroMarkedFiles はマークされたファイル名の空白区切りの一覧です。
+	// 空白を含むファイル名はダブルクォートで囲まれます。
+	MacroMarkedFiles = "$M"
+	// MacroVolumeLabel はアクティブウィンドウのドライブのボリュームラベルです。
+	MacroVolumeLabel = "$V"
+)
+
+// DriveInfo はドライブの情報を表します。
+type DriveInfo struct {
+	Drive string // ドライブ名（例: "C:" や `\\server\share`）
+	Label string // ボリュームラベル
+}
+
+// OppositePath は反対窓のカレントディレクトリを取得します。
+func OppositePath(ctx context.Context, a AFX) (string, error) {
+	path, err := a.Extract(ctx, MacroOppositePath)
+	if err != nil {
+		return "", fmt.Errorf("反対窓のパスの取得に失敗しました: %w", err)
+	}
+	return path, nil
+}
+
+// CursorFile はカーソル位置のファイル名を取得します。
+func CursorFile(ctx context.Context, a AFX) (string, error) {
+	name, err := a.Extract(ctx, MacroCursorFile)
+	if err != nil {
+		return "", fmt.Errorf("カーソル位置のファイル名の取得に失敗しました: %w", err)
+	}
+	return name, nil
+}
+
+// MarkedFileNames はマークされたファイル名の一覧を取得します。
+// マークがない場合は空のスライスを返します。
+func MarkedFileNames(ctx context.Context, a AFX) ([]string, error) {
+	list, err := a.Extract(ctx, MacroMarkedFiles)
+	if err != nil {
+		return nil, fmt.Errorf("マークファイルの取得に失敗しました: %w", err)
+	}
+	return SplitFileList(list), nil
+}
+
+// Drive はアクティブウィンドウのドライブ情報を取得します。
+func Drive(ctx context.Context, a AFX) (DriveInfo, error) {
+	path, err := a.Extract(ctx, MacroActivePath)
+	if err != nil {
+		return DriveInfo{}, fmt.Errorf("ドライブ情報の取得に失敗しました: %w", err)
+	}
+	label, err := a.Extract(ctx, MacroVolumeLabel)
+	if err != nil {
+		return DriveInfo{}, fmt.Errorf("ドライブ情報の取得に失敗しました: %w", err)
+	}
+	return DriveInfo{Drive: volumeName(path), Label: label}, nil
+}
+
+// SplitFileList は $M などが返す空白区切りのファイル一覧を分割します。
+// ダブルクォートで囲まれた部分は空白を含めて1つのファイル名として扱います。
+func SplitFileList(list string) []string {
+	names := []string{}
+	var sb strings.Builder
+	quoted := false
+	inToken := false
+	for _, r := range list {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inToken = true
+		case (r == ' ' || r == '\t') && !quoted:
+			if inToken && sb.Len() > 0 {
+				names = append(names, sb.String())
+			}
+			sb.Reset()
+			inToken = false
+		default:
+			sb.WriteRune(r)
+			inToken = true
+		}
+	}
+	if inToken && sb.Len() > 0 {
+		names = append(names, sb.String())
+	}
+	return names
+}
+
+// volumeName はパスの先頭にあるドライブ名（"C:" や `\\server\share`）を返します。
+// ドライブレターは大文字に揃えます。
+func volumeName(path string) string {
+	vol := winpath.VolumeName(path)
+	if len(vol) == 2 {
+		return strings.ToUpper(vol)
+	}
+	return vol
+}
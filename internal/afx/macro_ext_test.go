@@ -0,0 +1,53 @@
+package afx_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+)
+
+func TestMacroHelpers(t *testing.T) {
+	a := &afxtest.MockAFX{
+		ExtractResults: map[string]string{
+			afx.MacroActivePath:   `C:\Users\Test`,
+			afx.MacroOppositePath: `D:\作業`,
+			afx.MacroCursorFile:   `報告書.xlsx`,
+			afx.MacroMarkedFiles:  `a.txt "b c.txt"`,
+			afx.MacroVolumeLabel:  `Windows`,
+		},
+	}
+
+	if got, err := afx.OppositePath(t.Context(), a); err != nil || got != `D:\作業` {
+		t.Errorf("OppositePath: 取得: %q, %v", got, err)
+	}
+	if got, err := afx.CursorFile(t.Context(), a); err != nil || got != `報告書.xlsx` {
+		t.Errorf("CursorFile: 取得: %q, %v", got, err)
+	}
+	if got, err := afx.MarkedFileNames(t.Context(), a); err != nil || !reflect.DeepEqual(got, []string{"a.txt", "b c.txt"}) {
+		t.Errorf("MarkedFileNames: 取得: %q, %v", got, err)
+	}
+	want := afx.DriveInfo{Drive: "C:", Label: "Windows"}
+	if got, err := afx.Drive(t.Context(), a); err != nil || got != want {
+		t.Errorf("Drive: 期待: %+v, 取得: %+v, %v", want, got, err)
+	}
+}
+
+func TestMacroHelpers_Error(t *testing.T) {
+	a := &afxtest.MockAFX{ExtractErr: errors.New("extract error")}
+
+	if _, err := afx.OppositePath(t.Context(), a); err == nil {
+		t.Error("OppositePath: エラーが期待されましたが、nilが返りました")
+	}
+	if _, err := afx.CursorFile(t.Context(), a); err == nil {
+		t.Error("CursorFile: エラーが期待されましたが、nilが返りました")
+	}
+	if _, err := afx.MarkedFileNames(t.Context(), a); err == nil {
+		t.Error("MarkedFileNames: エラーが期待されましたが、nilが返りました")
+	}
+	if _, err := afx.Drive(t.Context(), a); err == nil {
+		t.Error("Drive: エラーが期待されましたが、nilが返りました")
+	}
+}
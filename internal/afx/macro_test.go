@@ -0,0 +1,55 @@
+package afx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitFileList(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"空文字列", ``, []string{}},
+		{"1件", `readme.txt`, []string{"readme.txt"}},
+		{"複数件", `a.txt b.txt c.txt`, []string{"a.txt", "b.txt", "c.txt"}},
+		{"空白を含む名前", `"My Documents" a.txt "新しい フォルダ"`, []string{"My Documents", "a.txt", "新しい フォルダ"}},
+		{"連続した空白", `  a.txt   b.txt  `, []string{"a.txt", "b.txt"}},
+		{"クォートされた単語", `"a.txt" b.txt`, []string{"a.txt", "b.txt"}},
+		{"空のクォート", `"" a.txt`, []string{"a.txt"}},
+		{"記号を含む名前", `"$data (1)" 100%.log`, []string{"$data (1)", "100%.log"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitFileList(tt.input)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestVolumeName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`C:\Users\Test`, `C:`},
+		{`d:\work`, `D:`},
+		{`C:`, `C:`},
+		{`\\server\share\dir`, `\\server\share`},
+		{`\\server\share`, `\\server\share`},
+		{`\\server`, ``},
+		{`relative\path`, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := volumeName(tt.input); got != tt.expected {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package afx
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/go-ole/go-ole"
+	"github.com/go-ole/go-ole/oleutil"
+)
+
+// oleAFX は COM 経由で afxw.obj を呼び出す backend です。
+// COM オブジェクトは作成したスレッドからしか呼び出せないため、
+// すべての呼び出しを OS スレッドに固定した専用のゴルーチンで実行します。
+type oleAFX struct {
+	calls chan func()
+
+	// 以下は専用ゴルーチンの中でのみ使用します
+	afxw    *ole.IDispatch
+	unknown *ole.IUnknown
+}
+
+// NewOleAFX は実際の afxw.obj と対話する新しい AFX インスタンスを作成します。
+// ctx に期限が設定されていなければ、afxw.obj の作成は DefaultTimeout で打ち切ります。
+func NewOleAFX(ctx context.Context) (AFX, error) {
+	ctx, cancel := withDefaultTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	a := &oleAFX{calls: make(chan func())}
+	ready := make(chan error, 1)
+	go a.serve(ready)
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			return nil, err
+		}
+	case <-ctx.Done():
+		// 打ち切った後で作成に成功した場合は、そのまま解放する
+		go func() {
+			if err := <-ready; err == nil {
+				close(a.calls)
+			}
+		}()
+		return nil, ctxErr(ctx)
+	}
+	return &client{b: a, timeout: DefaultTimeout}, nil
+}
+
+// serve は COM を初期化し、Close されるまで calls に送られた処理を順に実行します。
+func (a *oleAFX) serve(ready chan<- error) {
+	runtime.LockOSThread()
+	if err := a.init(); err != nil {
+		runtime.UnlockOSThread()
+		ready <- err
+		return
+	}
+	ready <- nil
+
+	for f := range a.calls {
+		f()
+	}
+	a.release()
+}
+
+// init は COM を初期化して afxw.obj を作成します。
+func (a *oleAFX) init() error {
+	success := false
+
+	if err := ole.CoInitialize(0); err != nil {
+		return fmt.Errorf("COMの初期化に失敗しました: %w", err)
+	}
+	defer func() {
+		if !success {
+			ole.CoUninitialize()
+		}
+	}()
+
+	unknown, err := oleutil.CreateObject("afxw.obj")
+	if err != nil {
+		return fmt.Errorf("afxw.objの作成に失敗しました: %w", err)
+	}
+	defer func() {
+		if !success {
+			unknown.Release()
+		}
+	}()
+
+	afxw, err := unknown.QueryInterface(ole.IID_IDispatch)
+	if err != nil {
+		return fmt.Errorf("IDispatchの取得に失敗しました: %w", err)
+	}
+
+	success = true
+	a.afxw = afxw
+	a.unknown = unknown
+	return nil
+}
+
+// call は f を COM 用のゴルーチンで実行し、完了するか ctx が終了するまで待ちます。
+// ctx が先に終了した場合、実行中の f は待たずに破棄されます。
+func call[T any](ctx context.Context, a *oleAFX, f func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+
+	var zero T
+	select {
+	case a.calls <- func() {
+		value, err := f()
+		done <- result{value, err}
+	}:
+	case <-ctx.Done():
+		return zero, ctxErr(ctx)
+	}
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return zero, ctxErr(ctx)
+	}
+}
+
+// HisDirCount は指定されたウィンドウの履歴件数を返します。
+func (a *oleAFX) HisDirCount(ctx context.Context, win int) (int, error) {
+	return call(ctx, a, func() (int, error) {
+		res, err := oleutil.CallMethod(a.afxw, "HisDirCount", win)
+		if err != nil {
+			return 0, err
+		}
+		defer res.Clear()
+		return int(res.Value().(int32)), nil
+	})
+}
+
+// HisDir は指定されたウィンドウの idx 番目の履歴を返します。
+func (a *oleAFX) HisDir(ctx context.Context, win, idx int) (string, error) {
+	return call(ctx, a, func() (string, error) {
+		res, err := oleutil.CallMethod(a.afxw, "HisDir", win, idx)
+		if err != nil {
+			return "", err
+		}
+		defer res.Clear()
+		return fmt.Sprint(res.Value()), nil
+	})
+}
+
+// Exec はあふwの内部コマンドを実行します。
+func (a *oleAFX) Exec(ctx context.Context, command string) error {
+	_, err := call(ctx, a, func() (struct{}, error) {
+		res, err := oleutil.CallMethod(a.afxw, "Exec", command)
+		if err != nil {
+			return struct{}{}, err
+		}
+		res.Clear()
+		return struct{}{}, nil
+	})
+	return err
+}
+
+// Extract はあふwのマクロを展開します。
+func (a *oleAFX) Extract(ctx context.Context, macro string) (string, error) {
+	return call(ctx, a, func() (string, error) {
+		res, err := oleutil.CallMethod(a.afxw, "Extract", macro)
+		if err != nil {
+			return "", err
+		}
+		defer res.Clear()
+		return fmt.Sprint(res.Value()), nil
+	})
+}
+
+// Close は COM 用のゴルーチンを終了させます。COM リソースはそのゴルーチンの中で解放されます。
+// あふwが応答せず処理中の呼び出しが終わらない場合でも、Close は待たずに戻ります。
+func (a *oleAFX) Close() {
+	if a.calls == nil {
+		a.release()
+		return
+	}
+	close(a.calls)
+}
+
+// release はCOMリソースを解放し、OSスレッドのロックを解除します。
+func (a *oleAFX) release() {
+	defer runtime.UnlockOSThread()
+	defer ole.CoUninitialize()
+
+	if a.afxw != nil {
+		a.afxw.Release()
+	}
+	if a.unknown != nil {
+		a.unknown.Release()
+	}
+}
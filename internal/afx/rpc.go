@@ -0,0 +1,110 @@
+package afx
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"strings"
+)
+
+// EnvRPC は JSON-RPC ブリッジの接続先を指定する環境変数です。
+// 値は "tcp://127.0.0.1:9000" や "unix:///tmp/afxw.sock" の形式で指定します。
+const EnvRPC = "AFXW_RPC"
+
+// RPCServiceName は JSON-RPC ブリッジが公開するサービス名です。
+// サーバーは afxw.obj と同名のメソッド（HisDirCount, HisDir, Exec, Extract）を公開します。
+const RPCServiceName = "Afxw"
+
+// HisDirArgs は HisDirCount と HisDir の引数です。
+type HisDirArgs struct {
+	Win   int
+	Index int
+}
+
+// rpcAFX は JSON-RPC でブリッジを呼び出す backend です。
+type rpcAFX struct {
+	c *rpc.Client
+}
+
+// DialRPC は JSON-RPC ブリッジに接続する新しい AFX インスタンスを作成します。
+// network には "tcp" または "unix" を指定します。
+func DialRPC(ctx context.Context, network, address string) (AFX, error) {
+	ctx, cancel := withDefaultTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, address)
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctxErr(ctx)
+		}
+		return nil, fmt.Errorf("JSON-RPCブリッジへの接続に失敗しました: %w", err)
+	}
+	return &client{b: &rpcAFX{c: jsonrpc.NewClient(conn)}, timeout: DefaultTimeout}, nil
+}
+
+// call はリモートのメソッドを呼び出し、応答があるか ctx が終了するまで待ちます。
+// ctx が先に終了した場合、reply は後から書き込まれる可能性があるため参照しないでください。
+func (r *rpcAFX) call(ctx context.Context, method string, args, reply any) error {
+	c := r.c.Go(RPCServiceName+"."+method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-c.Done:
+		return c.Error
+	case <-ctx.Done():
+		return ctxErr(ctx)
+	}
+}
+
+// HisDirCount は指定されたウィンドウの履歴件数を返します。
+func (r *rpcAFX) HisDirCount(ctx context.Context, win int) (int, error) {
+	var count int
+	if err := r.call(ctx, "HisDirCount", HisDirArgs{Win: win}, &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// HisDir は指定されたウィンドウの idx 番目の履歴を返します。
+func (r *rpcAFX) HisDir(ctx context.Context, win, idx int) (string, error) {
+	var dir string
+	if err := r.call(ctx, "HisDir", HisDirArgs{Win: win, Index: idx}, &dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Exec はあふwの内部コマンドを実行します。
+func (r *rpcAFX) Exec(ctx context.Context, command string) error {
+	var ok bool
+	return r.call(ctx, "Exec", command, &ok)
+}
+
+// Extract はあふwのマクロを展開します。
+func (r *rpcAFX) Extract(ctx context.Context, macro string) (string, error) {
+	var value string
+	if err := r.call(ctx, "Extract", macro, &value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// Close は接続を閉じます。
+func (r *rpcAFX) Close() {
+	r.c.Close()
+}
+
+// parseRPCTarget は "tcp://host:port" 形式の接続先をネットワーク種別とアドレスに分解します。
+func parseRPCTarget(target string) (string, string, error) {
+	network, address, ok := strings.Cut(target, "://")
+	if !ok || address == "" {
+		return "", "", fmt.Errorf("%s の形式が不正です: %s", EnvRPC, target)
+	}
+	switch network {
+	case "tcp", "unix":
+		return network, address, nil
+	default:
+		return "", "", fmt.Errorf("%s のネットワーク種別が不正です: %s", EnvRPC, network)
+	}
+}
@@ -0,0 +1,28 @@
+package afxtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/fakeafxw"
+)
+
+// DialFake は偽のあふwサーバーを起動し、JSON-RPC で接続した afx.AFX を返します。
+// サーバーと接続はテスト終了時に閉じられます。
+func DialFake(t testing.TB, s *fakeafxw.Server) afx.AFX {
+	t.Helper()
+
+	l, err := s.Start("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("偽のあふwの起動に失敗しました: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	a, err := afx.DialRPC(context.Background(), "tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("偽のあふwへの接続に失敗しました: %v", err)
+	}
+	t.Cleanup(a.Close)
+	return a
+}
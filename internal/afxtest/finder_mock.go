@@ -1,11 +1,42 @@
 package afxtest
 
+import (
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/tana9/afxw-tools/internal/finder"
+)
+
 // MockFinder は finder.Finder インターフェースのテスト用モックです。
+// Steps が設定されている場合は呼び出しごとに先頭から順に結果を返し、
+// 使い切った後は fuzzyfinder.ErrAbort を返します。
 type MockFinder struct {
+	Idx   int
+	Err   error
+	Steps []FinderStep
+	Items []string       // 最後に Find に渡された候補
+	Calls [][]string     // Find に渡された候補（呼び出し順）
+	Opts  finder.Options // 最後に FindWithOptions に渡された指定
+}
+
+// FinderStep は MockFinder の1回の呼び出しの結果です。
+type FinderStep struct {
 	Idx int
 	Err error
 }
 
 func (m *MockFinder) Find(items []string) (int, error) {
-	return m.Idx, m.Err
+	m.Items = items
+	m.Calls = append(m.Calls, items)
+	if m.Steps == nil {
+		return m.Idx, m.Err
+	}
+	if len(m.Calls) > len(m.Steps) {
+		return 0, fuzzyfinder.ErrAbort
+	}
+	step := m.Steps[len(m.Calls)-1]
+	return step.Idx, step.Err
+}
+
+func (m *MockFinder) FindWithOptions(items []string, opts finder.Options) (int, error) {
+	m.Opts = opts
+	return m.Find(items)
 }
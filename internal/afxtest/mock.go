@@ -1,6 +1,10 @@
 package afxtest
 
-import "github.com/tana9/afxw-tools/internal/afx"
+import (
+	"context"
+
+	"github.com/tana9/afxw-tools/internal/afx"
+)
 
 // MockAFX は afx.AFX インターフェースのテスト用モックです。
 type MockAFX struct {
@@ -13,12 +17,31 @@ type MockAFX struct {
 	HistoriesByWin map[int][]string
 	// ReceivedWins は Histories に渡された wins 引数を記録します。
 	ReceivedWins []int
+	// ExcdWin は EXCDIn に渡されたウィンドウ番号を記録します。
+	ExcdWin int
+	// ExcdOppositePath は EXCDOpposite に渡されたパスを記録します。
+	ExcdOppositePath string
+	// Swapped は Swap が呼ばれたかを記録します。
+	Swapped bool
+	SwapErr error
+	// CursorName は Cursor に渡されたファイル名を記録します。
+	CursorName string
+	CursorErr  error
+	// PathsByWin はウィンドウ番号ごとのカレントディレクトリを設定します。
+	PathsByWin map[int]string
+	PathOfErr  error
+	// ExtractResults はマクロごとの展開結果を設定します。
+	ExtractResults map[string]string
+	ExtractErr     error
+	// MarkedFilesResult は MarkedFiles の戻り値を設定します。
+	MarkedFilesResult []string
+	MarkedFilesErr    error
 }
 
 // インターフェースの実装を保証するコンパイル時チェック
 var _ afx.AFX = (*MockAFX)(nil)
 
-func (m *MockAFX) Histories(wins []int) ([]string, error) {
+func (m *MockAFX) Histories(ctx context.Context, wins []int) ([]string, error) {
 	m.ReceivedWins = wins
 	if m.HistoriesErr != nil {
 		return nil, m.HistoriesErr
@@ -33,16 +56,70 @@ func (m *MockAFX) Histories(wins []int) ([]string, error) {
 	return m.HistoriesResult, nil
 }
 
-func (m *MockAFX) EXCD(path string) error {
+func (m *MockAFX) EXCD(ctx context.Context, path string) error {
+	if m.ExcdErr != nil {
+		return m.ExcdErr
+	}
+	m.ExcdPath = path
+	return nil
+}
+
+func (m *MockAFX) EXCDIn(ctx context.Context, win int, path string) error {
 	if m.ExcdErr != nil {
 		return m.ExcdErr
 	}
+	m.ExcdWin = win
 	m.ExcdPath = path
 	return nil
 }
 
-func (m *MockAFX) GetActivePath() (string, error) {
-	return "", nil
+func (m *MockAFX) EXCDOpposite(ctx context.Context, path string) error {
+	if m.ExcdErr != nil {
+		return m.ExcdErr
+	}
+	m.ExcdOppositePath = path
+	return nil
+}
+
+func (m *MockAFX) Swap(ctx context.Context) error {
+	if m.SwapErr != nil {
+		return m.SwapErr
+	}
+	m.Swapped = true
+	return nil
+}
+
+func (m *MockAFX) Cursor(ctx context.Context, name string) error {
+	if m.CursorErr != nil {
+		return m.CursorErr
+	}
+	m.CursorName = name
+	return nil
+}
+
+func (m *MockAFX) Extract(ctx context.Context, macro string) (string, error) {
+	if m.ExtractErr != nil {
+		return "", m.ExtractErr
+	}
+	return m.ExtractResults[macro], nil
+}
+
+func (m *MockAFX) GetActivePath(ctx context.Context) (string, error) {
+	return m.Extract(ctx, afx.MacroActivePath)
+}
+
+func (m *MockAFX) PathOf(ctx context.Context, win int) (string, error) {
+	if m.PathOfErr != nil {
+		return "", m.PathOfErr
+	}
+	return m.PathsByWin[win], nil
+}
+
+func (m *MockAFX) MarkedFiles(ctx context.Context) ([]string, error) {
+	if m.MarkedFilesErr != nil {
+		return nil, m.MarkedFilesErr
+	}
+	return m.MarkedFilesResult, nil
 }
 
 func (m *MockAFX) Close() {}
@@ -0,0 +1,83 @@
+// Package config は afxw-tools の各ツールで共有する設定ファイルを読み込みます。
+//
+// 設定ファイルは %APPDATA%\afxw-tools\config.toml です。ファイルがない場合はすべて既定値になります。
+// afxw-bm と zoxide への記録（afxw-his / afxw-bm / afxw-zox）がこのファイルを読み込みます。
+// afxw-launcher のメニューはこのファイルではなく、afxw-launcher の config パッケージが読み込む
+// ~/.config/afxw-launcher/config.toml に記述します。
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// FileName は設定ファイルの名前です。
+const FileName = "config.toml"
+
+// Config は共有の設定ファイルの内容を表します。
+type Config struct {
+	Bookmark Bookmark `toml:"bookmark"`
+	Zoxide   Zoxide   `toml:"zoxide"`
+}
+
+// Zoxide は zoxide との連携の設定を表します。
+type Zoxide struct {
+	// Record はあふwでの移動を zoxide のデータベースに記録するかです。
+	// 有効にすると afxw-his、afxw-bm、afxw-zox で移動したディレクトリがターミナルの z からも使えるようになります。
+	Record bool `toml:"record"`
+}
+
+// Bookmark は afxw-bm の設定を表します。
+type Bookmark struct {
+	// File は個人用のブックマークファイルのパスです（%VAR% や ~ を使用可）。
+	// 省略時は %APPDATA%\afxw-tools\bookmarks.toml を使います。
+	File string `toml:"file"`
+
+	// Sources は個人用のブックマークファイルに加えて読み込むファイルです。
+	// 記述した順に表示し、同じディレクトリは先に読み込んだものを優先します。
+	Sources []BookmarkSource `toml:"source"`
+}
+
+// BookmarkSource は追加で読み込むブックマークファイルを表します。
+type BookmarkSource struct {
+	Label    string `toml:"label"`    // ファインダーに表示する名前（省略時はファイル名）
+	Path     string `toml:"path"`     // ブックマークファイルのパス（%VAR% や ~ を使用可）
+	ReadOnly bool   `toml:"readonly"` // 移動の記録や削除などでファイルを変更しない
+}
+
+// DefaultPath は設定ファイルのデフォルトパスを返します。
+// Windows では %APPDATA%\afxw-tools\config.toml になります。
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "afxw-tools", FileName), nil
+}
+
+// Load は指定されたファイルから設定を読み込みます。
+// ファイルが存在しない場合は既定の設定を返します。
+func Load(path string) (*Config, error) {
+	var cfg Config
+	if _, err := toml.DecodeFile(path, &cfg); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("設定ファイルの読み込みに失敗しました (%s): %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// LoadDefault は既定の設定ファイルから設定を読み込みます。
+func LoadDefault() (*Config, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, fmt.Errorf("設定ファイルのパス取得に失敗しました: %w", err)
+	}
+	return Load(path)
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	content := `[bookmark]
+file = 'D:\sync\bookmarks.toml'
+
+[[bookmark.source]]
+label = "チーム"
+path = '\\server\share\bookmarks.toml'
+readonly = true
+
+[[bookmark.source]]
+path = '%USERPROFILE%\work.toml'
+
+[zoxide]
+record = true
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	expected := []BookmarkSource{
+		{Label: "チーム", Path: `\\server\share\bookmarks.toml`, ReadOnly: true},
+		{Path: `%USERPROFILE%\work.toml`},
+	}
+	if cfg.Bookmark.File != `D:\sync\bookmarks.toml` {
+		t.Errorf("期待: D:\\sync\\bookmarks.toml, 取得: %q", cfg.Bookmark.File)
+	}
+	if !reflect.DeepEqual(cfg.Bookmark.Sources, expected) {
+		t.Errorf("期待: %+v, 取得: %+v", expected, cfg.Bookmark.Sources)
+	}
+	if !cfg.Zoxide.Record {
+		t.Error("zoxide.record が読み込まれていません")
+	}
+}
+
+func TestLoad_NonExistentFile(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, &Config{}) {
+		t.Errorf("既定の設定が期待されましたが、%+v が返りました", cfg)
+	}
+}
+
+func TestLoad_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("[[bookmark.source]\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("エラーが期待されましたが、nilが返りました")
+	}
+}
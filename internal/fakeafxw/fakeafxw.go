@@ -0,0 +1,292 @@
+// Package fakeafxw はメモリ上で動く偽のあふwを JSON-RPC ブリッジとして提供します。
+//
+// afx.DialRPC で接続すると afx.AFX として利用できるため、
+// Windows やあふwがない環境でもツールの処理全体をテストできます。
+package fakeafxw
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/afx/cmd"
+	"github.com/tana9/afxw-tools/internal/winpath"
+)
+
+// DefaultHistoryLimit は各ウィンドウの履歴リングの既定の長さです。
+const DefaultHistoryLimit = 20
+
+// Server は左右のウィンドウの履歴リングとアクティブウィンドウをメモリ上に保持する偽のあふwです。
+// 各ウィンドウの履歴の先頭がそのウィンドウのカレントディレクトリです。
+type Server struct {
+	// HistoryLimit は各ウィンドウで保持する履歴の最大件数です。
+	HistoryLimit int
+
+	mu        sync.Mutex
+	active    int
+	histories [2][]string
+	marked    []string
+	cursor    string
+	label     string
+	executed  []string
+	delay     time.Duration
+}
+
+// New は左右のカレントディレクトリを指定して新しい Server を作成します。
+// アクティブウィンドウは左窓です。
+func New(left, right string) *Server {
+	return &Server{
+		HistoryLimit: DefaultHistoryLimit,
+		active:       afx.WindowLeft,
+		histories:    [2][]string{{winpath.Clean(left)}, {winpath.Clean(right)}},
+	}
+}
+
+// Start は指定されたアドレスで待ち受けを開始し、接続をバックグラウンドで処理します。
+// 返されたリスナーを閉じるとサーバーは停止します。
+func (s *Server) Start(network, address string) (net.Listener, error) {
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("待ち受けの開始に失敗しました: %w", err)
+	}
+	go s.Serve(l)
+	return l, nil
+}
+
+// Serve はリスナーで受け付けた接続を処理します。リスナーが閉じられるまで戻りません。
+func (s *Server) Serve(l net.Listener) error {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName(afx.RPCServiceName, &service{s: s}); err != nil {
+		return err
+	}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// SetActive はアクティブウィンドウを設定します。
+func (s *Server) SetActive(win int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = win
+}
+
+// Active はアクティブウィンドウを返します。
+func (s *Server) Active() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+// Path は指定されたウィンドウのカレントディレクトリを返します。
+func (s *Server) Path(win int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.histories[win][0]
+}
+
+// SetHistory は指定されたウィンドウの履歴を設定します。先頭がカレントディレクトリになります。
+func (s *Server) SetHistory(win int, dirs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.histories[win] = nil
+	for _, dir := range dirs {
+		s.histories[win] = append(s.histories[win], winpath.Clean(dir))
+	}
+}
+
+// History は指定されたウィンドウの履歴を返します。
+func (s *Server) History(win int) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.histories[win]...)
+}
+
+// SetMarked はアクティブウィンドウでマークされているファイル名を設定します。
+func (s *Server) SetMarked(names ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked = names
+}
+
+// SetCursor はカーソル位置のファイル名を設定します。
+func (s *Server) SetCursor(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursor = name
+}
+
+// Cursor はカーソル位置のファイル名を返します。
+func (s *Server) Cursor() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cursor
+}
+
+// SetVolumeLabel はボリュームラベルを設定します。
+func (s *Server) SetVolumeLabel(label string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.label = label
+}
+
+// SetDelay は各呼び出しに応答するまでの遅延を設定します。
+// あふwがダイアログ表示中などで応答しない状況の再現に使います。
+func (s *Server) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// wait は設定された遅延だけ待ちます。
+func (s *Server) wait() {
+	s.mu.Lock()
+	d := s.delay
+	s.mu.Unlock()
+	time.Sleep(d)
+}
+
+// Executed は Exec で実行されたコマンドを実行順に返します。
+func (s *Server) Executed() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.executed...)
+}
+
+// macros は現在の状態でのマクロの展開結果を返します。呼び出し側でロックを取得してください。
+func (s *Server) macros() map[string]string {
+	marked := make([]string, len(s.marked))
+	for i, name := range s.marked {
+		if strings.ContainsAny(name, " \t") {
+			name = `"` + name + `"`
+		}
+		marked[i] = name
+	}
+	return map[string]string{
+		afx.MacroActivePath:   s.histories[s.active][0],
+		afx.MacroOppositePath: s.histories[1-s.active][0],
+		afx.MacroCursorFile:   s.cursor,
+		afx.MacroMarkedFiles:  strings.Join(marked, " "),
+		afx.MacroVolumeLabel:  s.label,
+	}
+}
+
+// exec は内部コマンドを解釈して状態を更新します。呼び出し側でロックを取得してください。
+func (s *Server) exec(command string) error {
+	s.executed = append(s.executed, command)
+
+	name, args, err := cmd.Parse(command)
+	if err != nil {
+		return err
+	}
+
+	// $P や $O はコマンド実行前の状態で展開する（&EXCD -P"$O" -O"$P" で入れ替えられるように）
+	macros := s.macros()
+	switch name {
+	case "EXCD":
+		for _, arg := range args {
+			path := cmd.Expand(arg.Value, macros)
+			switch arg.Flag {
+			case "P":
+				s.chdir(s.active, path)
+			case "O":
+				s.chdir(1-s.active, path)
+			default:
+				return fmt.Errorf("EXCD の未知のオプションです: -%s", arg.Flag)
+			}
+		}
+	case "CURSOR":
+		if len(args) != 1 {
+			return fmt.Errorf("CURSOR の引数が不正です: %s", command)
+		}
+		s.cursor = cmd.Expand(args[0].Value, macros)
+	}
+	return nil
+}
+
+// chdir は指定されたウィンドウのカレントディレクトリを変更し、履歴の先頭に追加します。
+func (s *Server) chdir(win int, path string) {
+	path = winpath.Clean(path)
+	history := []string{path}
+	for _, dir := range s.histories[win] {
+		if !winpath.Equal(dir, path) {
+			history = append(history, dir)
+		}
+	}
+	if s.HistoryLimit > 0 && len(history) > s.HistoryLimit {
+		history = history[:s.HistoryLimit]
+	}
+	s.histories[win] = history
+	s.cursor = ""
+}
+
+// service は net/rpc に登録する afxw.obj 互換のメソッドを提供します。
+type service struct {
+	s *Server
+}
+
+func (v *service) HisDirCount(args afx.HisDirArgs, reply *int) error {
+	v.s.wait()
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := checkWindow(args.Win); err != nil {
+		return err
+	}
+	*reply = len(v.s.histories[args.Win])
+	return nil
+}
+
+func (v *service) HisDir(args afx.HisDirArgs, reply *string) error {
+	v.s.wait()
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := checkWindow(args.Win); err != nil {
+		return err
+	}
+	history := v.s.histories[args.Win]
+	if args.Index < 0 || args.Index >= len(history) {
+		return fmt.Errorf("履歴の範囲外です: %d", args.Index)
+	}
+	*reply = history[args.Index]
+	return nil
+}
+
+func (v *service) Exec(command string, reply *bool) error {
+	v.s.wait()
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	if err := v.s.exec(command); err != nil {
+		return err
+	}
+	*reply = true
+	return nil
+}
+
+func (v *service) Extract(macro string, reply *string) error {
+	v.s.wait()
+	v.s.mu.Lock()
+	defer v.s.mu.Unlock()
+	*reply = cmd.Expand(macro, v.s.macros())
+	return nil
+}
+
+// checkWindow はウィンドウ番号が有効かを確認します。
+func checkWindow(win int) error {
+	if win != afx.WindowLeft && win != afx.WindowRight {
+		return fmt.Errorf("無効なウィンドウ番号です: %d", win)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package fakeafxw_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+	"github.com/tana9/afxw-tools/internal/fakeafxw"
+)
+
+func TestHistories(t *testing.T) {
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	s.SetHistory(afx.WindowLeft, `C:\Left`, `C:\Old`)
+	a := afxtest.DialFake(t, s)
+
+	dirs, err := a.Histories(t.Context(), []int{afx.WindowLeft, afx.WindowRight})
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	expected := []string{`C:\Left`, `C:\Old`, `D:\Right`}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, dirs)
+	}
+}
+
+func TestEXCD(t *testing.T) {
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	a := afxtest.DialFake(t, s)
+
+	if err := a.EXCD(t.Context(), `C:\プロジェクト\$見積 "A"`); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	if got := s.Path(afx.WindowLeft); got != `C:\プロジェクト\$見積 "A"` {
+		t.Errorf("左窓 期待: %q, 取得: %q", `C:\プロジェクト\$見積 "A"`, got)
+	}
+	if got := s.Path(afx.WindowRight); got != `D:\Right` {
+		t.Errorf("右窓は変更されるべきではありません: %q", got)
+	}
+	expected := []string{`C:\プロジェクト\$見積 "A"`, `C:\Left`}
+	if got := s.History(afx.WindowLeft); !reflect.DeepEqual(got, expected) {
+		t.Errorf("履歴 期待: %q, 取得: %q", expected, got)
+	}
+}
+
+func TestEXCDOppositeAndIn(t *testing.T) {
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	s.SetActive(afx.WindowRight)
+	a := afxtest.DialFake(t, s)
+
+	if err := a.EXCDOpposite(t.Context(), `C:\Opposite`); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if got := s.Path(afx.WindowLeft); got != `C:\Opposite` {
+		t.Errorf("左窓 期待: %q, 取得: %q", `C:\Opposite`, got)
+	}
+
+	if err := a.EXCDIn(t.Context(), afx.WindowRight, `D:\In`); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if got := s.Path(afx.WindowRight); got != `D:\In` {
+		t.Errorf("右窓 期待: %q, 取得: %q", `D:\In`, got)
+	}
+
+	if err := a.EXCDIn(t.Context(), afx.WindowLeft, `C:\In`); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if got := s.Path(afx.WindowLeft); got != `C:\In` {
+		t.Errorf("左窓 期待: %q, 取得: %q", `C:\In`, got)
+	}
+}
+
+func TestEXCDIn_SamePath(t *testing.T) {
+	tests := []struct {
+		name          string
+		active        int
+		win           int
+		expectedLeft  string
+		expectedRight string
+	}{
+		{"左窓がアクティブで左窓を移動", afx.WindowLeft, afx.WindowLeft, `E:\In`, `C:\Same`},
+		{"左窓がアクティブで右窓を移動", afx.WindowLeft, afx.WindowRight, `C:\Same`, `E:\In`},
+		{"右窓がアクティブで左窓を移動", afx.WindowRight, afx.WindowLeft, `E:\In`, `C:\Same`},
+		{"右窓がアクティブで右窓を移動", afx.WindowRight, afx.WindowRight, `C:\Same`, `E:\In`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := fakeafxw.New(`C:\Same`, `C:\Same`)
+			s.SetActive(tt.active)
+			a := afxtest.DialFake(t, s)
+
+			if _, err := afx.ActiveWindow(t.Context(), a); !errors.Is(err, afx.ErrAmbiguousWindow) {
+				t.Errorf("ErrAmbiguousWindow が期待されましたが、%v が返りました", err)
+			}
+			if err := a.EXCDIn(t.Context(), tt.win, `E:\In`); err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if got := s.Path(afx.WindowLeft); got != tt.expectedLeft {
+				t.Errorf("左窓 期待: %q, 取得: %q", tt.expectedLeft, got)
+			}
+			if got := s.Path(afx.WindowRight); got != tt.expectedRight {
+				t.Errorf("右窓 期待: %q, 取得: %q", tt.expectedRight, got)
+			}
+			if got := s.Active(); got != tt.active {
+				t.Errorf("アクティブウィンドウは変更されるべきではありません: %d", got)
+			}
+		})
+	}
+}
+
+func TestSwapAndPathOf(t *testing.T) {
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	a := afxtest.DialFake(t, s)
+
+	if err := a.Swap(t.Context()); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	left, err := a.PathOf(t.Context(), afx.WindowLeft)
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	right, err := a.PathOf(t.Context(), afx.WindowRight)
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if left != `D:\Right` || right != `C:\Left` {
+		t.Errorf("入れ替え後 期待: D:\\Right C:\\Left, 取得: %s %s", left, right)
+	}
+}
+
+func TestJumpPair(t *testing.T) {
+	tests := []struct {
+		name          string
+		same          bool // 移動前の左右を同じディレクトリにする
+		active        int
+		left, right   string
+		expectedLeft  string
+		expectedRight string
+	}{
+		{"左窓がアクティブ", false, afx.WindowLeft, `C:\Src`, `E:\Deploy`, `C:\Src`, `E:\Deploy`},
+		{"右窓がアクティブ", false, afx.WindowRight, `C:\Src`, `E:\Deploy`, `C:\Src`, `E:\Deploy`},
+		// 左窓を移動した時点で左右が同じディレクトリになっても右窓を取り違えない
+		{"途中で左右が同じになる", false, afx.WindowRight, `D:\Right`, `E:\Deploy`, `D:\Right`, `E:\Deploy`},
+		{"空のパスの窓は移動しない", false, afx.WindowLeft, "", `E:\Deploy`, `C:\Left`, `E:\Deploy`},
+		// 移動前から左右が同じディレクトリで右窓がアクティブでも左右を取り違えない
+		{"左右が同じで右窓がアクティブ", true, afx.WindowRight, `C:\Src`, `E:\Deploy`, `C:\Src`, `E:\Deploy`},
+		{"左右が同じで左窓がアクティブ", true, afx.WindowLeft, `C:\Src`, `E:\Deploy`, `C:\Src`, `E:\Deploy`},
+		{"左右が同じで右窓だけ移動", true, afx.WindowRight, "", `E:\Deploy`, `C:\Same`, `E:\Deploy`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, right := `C:\Left`, `D:\Right`
+			if tt.same {
+				left, right = `C:\Same`, `C:\Same`
+			}
+			s := fakeafxw.New(left, right)
+			s.SetActive(tt.active)
+			a := afxtest.DialFake(t, s)
+
+			if err := afx.JumpPair(t.Context(), a, tt.left, tt.right); err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if got := s.Path(afx.WindowLeft); got != tt.expectedLeft {
+				t.Errorf("左窓 期待: %q, 取得: %q", tt.expectedLeft, got)
+			}
+			if got := s.Path(afx.WindowRight); got != tt.expectedRight {
+				t.Errorf("右窓 期待: %q, 取得: %q", tt.expectedRight, got)
+			}
+			if got := s.Active(); got != tt.active {
+				t.Errorf("アクティブウィンドウは変更されるべきではありません: %d", got)
+			}
+		})
+	}
+}
+
+func TestJumpToFile(t *testing.T) {
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	a := afxtest.DialFake(t, s)
+
+	if err := afx.JumpToFile(t.Context(), a, `C:\資料\$見積 "A".xlsx`, false); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if got := s.Path(afx.WindowLeft); got != `C:\資料` {
+		t.Errorf("左窓 期待: C:\\資料, 取得: %q", got)
+	}
+	if got := s.Cursor(); got != `$見積 "A".xlsx` {
+		t.Errorf("カーソル 期待: %q, 取得: %q", `$見積 "A".xlsx`, got)
+	}
+
+	// 反対窓ではディレクトリだけを移動する
+	if err := afx.JumpToFile(t.Context(), a, `E:\共有\memo.txt`, true); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if got := s.Path(afx.WindowRight); got != `E:\共有` {
+		t.Errorf("右窓 期待: E:\\共有, 取得: %q", got)
+	}
+	if got := s.Cursor(); got != "" {
+		t.Errorf("反対窓への移動でカーソルを移動するべきではありません: %q", got)
+	}
+}
+
+func TestMarkedFilesAndExtract(t *testing.T) {
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	s.SetMarked("a.txt", "新しい フォルダ")
+	s.SetCursor("報告書.xlsx")
+	a := afxtest.DialFake(t, s)
+
+	files, err := a.MarkedFiles(t.Context())
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	expected := []string{`C:\Left\a.txt`, `C:\Left\新しい フォルダ`}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, files)
+	}
+
+	if got, err := afx.CursorFile(t.Context(), a); err != nil || got != "報告書.xlsx" {
+		t.Errorf("CursorFile: 取得: %q, %v", got, err)
+	}
+	if got, err := afx.OppositePath(t.Context(), a); err != nil || got != `D:\Right` {
+		t.Errorf("OppositePath: 取得: %q, %v", got, err)
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	s := fakeafxw.New(`C:\0`, `D:\`)
+	s.HistoryLimit = 3
+	a := afxtest.DialFake(t, s)
+
+	for _, dir := range []string{`C:\1`, `C:\2`, `C:\3`, `C:\1`} {
+		if err := a.EXCD(t.Context(), dir); err != nil {
+			t.Fatalf("予期しないエラー: %v", err)
+		}
+	}
+
+	expected := []string{`C:\1`, `C:\3`, `C:\2`}
+	if got := s.History(afx.WindowLeft); !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, got)
+	}
+}
+
+func TestInvalidWindow(t *testing.T) {
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	a := afxtest.DialFake(t, s)
+
+	if _, err := a.Histories(t.Context(), []int{2}); err == nil {
+		t.Error("エラーが期待されましたが、nilが返りました")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	s.SetDelay(300 * time.Millisecond)
+	a := afxtest.DialFake(t, s)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := a.EXCD(ctx, `C:\Work`)
+	if !errors.Is(err, afx.ErrTimeout) {
+		t.Fatalf("ErrTimeout を期待しましたが、取得: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("タイムアウト後すぐに戻るべきですが、%v かかりました", elapsed)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	s := fakeafxw.New(`C:\Left`, `D:\Right`)
+	s.SetDelay(300 * time.Millisecond)
+	a := afxtest.DialFake(t, s)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := a.Histories(ctx, []int{afx.WindowLeft})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("context.Canceled を期待しましたが、取得: %v", err)
+	}
+	if errors.Is(err, afx.ErrTimeout) {
+		t.Errorf("キャンセルはタイムアウトとして扱われるべきではありません: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+// Package fileutil は afxw-tools が設定や履歴のファイルを安全に書き換えるための処理をまとめます。
+package fileutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// renameRetries は一時ファイルでの置き換えを再試行する回数です。
+	renameRetries = 20
+	// renameRetryInterval は置き換えを再試行するまでの待ち時間です。
+	renameRetryInterval = 50 * time.Millisecond
+)
+
+// WriteAtomic は data を path と同じディレクトリの一時ファイルに書き込んでから path と置き換えます。
+// 書き込み途中のファイルが残ったり、他のプロセスから書き込み途中の内容が見えたりすることはありません。
+// ディレクトリが存在しない場合は作成します。
+// Windows では他のプロセスが path を読み込み中だと置き換えに失敗することがあるため、少し待って再試行します。
+func WriteAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("ディレクトリの作成に失敗しました: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("一時ファイルの作成に失敗しました: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("一時ファイルへの書き込みに失敗しました: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("一時ファイルへの書き込みに失敗しました: %w", err)
+	}
+
+	for range renameRetries {
+		if err = os.Rename(tmp.Name(), path); err == nil {
+			return nil
+		}
+		time.Sleep(renameRetryInterval)
+	}
+	return fmt.Errorf("%s の置き換えに失敗しました: %w", filepath.Base(path), err)
+}
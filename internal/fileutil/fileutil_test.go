@@ -0,0 +1,48 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "data.json")
+
+	// ディレクトリがなければ作成し、既存のファイルは置き換える
+	for _, data := range []string{"first", "second"} {
+		if err := WriteAtomic(path, []byte(data)); err != nil {
+			t.Fatalf("予期しないエラー: %v", err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("読み込みに失敗しました: %v", err)
+		}
+		if string(got) != data {
+			t.Errorf("期待: %q, 取得: %q", data, got)
+		}
+	}
+
+	// 一時ファイルを残さない
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("一時ファイルが残っています: %v", entries)
+	}
+}
+
+func TestWriteAtomic_DirError(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	// 親がファイルの場合はディレクトリを作成できない
+	if err := WriteAtomic(filepath.Join(file, "data.json"), []byte("x")); err == nil {
+		t.Error("エラーが期待されましたが、nilが返りました")
+	}
+}
@@ -0,0 +1,57 @@
+package fileutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrLockTimeout はファイルのロックを取得できなかったことを表します。
+var ErrLockTimeout = errors.New("ファイルが他のプロセスに使用されています")
+
+const (
+	// lockTimeout はロックの取得を待つ最大時間です。
+	lockTimeout = 10 * time.Second
+	// lockRetryInterval はロックの取得を再試行する間隔です。
+	lockRetryInterval = 10 * time.Millisecond
+)
+
+// LockPath は path のロックファイルのパスを返します。
+// ロックファイルは削除すると競合の原因になるため、作成したまま残します。
+func LockPath(path string) string {
+	return path + ".lock"
+}
+
+// WithLock は path のロックを取得してから fn を実行します。
+// ロックは OS のファイルロック（Windows は LockFileEx、それ以外は flock）で取得するため、
+// 別プロセス間でも排他され、プロセスが異常終了した場合も自動的に解放されます。
+func WithLock(path string, fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("ディレクトリの作成に失敗しました: %w", err)
+	}
+	f, err := os.OpenFile(LockPath(path), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("ロックファイルのオープンに失敗しました: %w", err)
+	}
+	defer f.Close()
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			return fmt.Errorf("%s のロックに失敗しました: %w", filepath.Base(path), err)
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s", ErrLockTimeout, filepath.Base(path))
+		}
+		time.Sleep(lockRetryInterval)
+	}
+	defer unlock(f)
+
+	return fn()
+}
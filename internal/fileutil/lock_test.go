@@ -0,0 +1,31 @@
+package fileutil
+
+import (
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithLock_Exclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.toml")
+
+	// ロック中は他の WithLock が fn を実行しないことを確認する
+	var inside atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			err := WithLock(path, func() error {
+				if n := inside.Add(1); n != 1 {
+					t.Errorf("同時に %d 個の fn が実行されています", n)
+				}
+				defer inside.Add(-1)
+				return nil
+			})
+			if err != nil {
+				t.Errorf("ロックに失敗しました: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+}
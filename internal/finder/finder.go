@@ -7,6 +7,27 @@ type Finder interface {
 	Find(items []string) (int, error)
 }
 
+// Options は Finder の表示を指定します。
+type Options struct {
+	Header string // 一覧の上に表示する見出し
+	Query  string // 検索語の初期値
+	Back   bool   // 検索語が空の状態で Backspace を押したときに ErrBack を返す（TUIFinder のみ）
+}
+
+// OptionsFinder は Options に対応した Finder です。
+type OptionsFinder interface {
+	Finder
+	FindWithOptions(items []string, opts Options) (int, error)
+}
+
+// FindWithOptions は f が OptionsFinder であれば opts を指定して、そうでなければ opts を無視して検索します。
+func FindWithOptions(f Finder, items []string, opts Options) (int, error) {
+	if of, ok := f.(OptionsFinder); ok {
+		return of.FindWithOptions(items, opts)
+	}
+	return f.Find(items)
+}
+
 type GoFuzzyFinder struct{}
 
 func (f *GoFuzzyFinder) Find(items []string) (int, error) {
@@ -14,3 +35,9 @@ func (f *GoFuzzyFinder) Find(items []string) (int, error) {
 		return items[i]
 	})
 }
+
+func (f *GoFuzzyFinder) FindWithOptions(items []string, opts Options) (int, error) {
+	return fuzzyfinder.Find(items, func(i int) string {
+		return items[i]
+	}, fuzzyfinder.WithHeader(opts.Header), fuzzyfinder.WithQuery(opts.Query))
+}
@@ -0,0 +1,260 @@
+package finder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/mattn/go-runewidth"
+)
+
+// ErrBack は検索語が空の状態で Backspace が押され、上の階層に戻る操作が行われたことを表します。
+var ErrBack = errors.New("上の階層に戻ります")
+
+// TUIFinder は階層をたどる操作に対応した Finder です。
+// Options.Back を指定した場合は、検索語が空の状態で Backspace を押すと ErrBack を返します。
+// Esc や Ctrl+C でキャンセルした場合は GoFuzzyFinder と同じく fuzzyfinder.ErrAbort を返します。
+type TUIFinder struct{}
+
+func (f *TUIFinder) Find(items []string) (int, error) {
+	return f.FindWithOptions(items, Options{})
+}
+
+func (f *TUIFinder) FindWithOptions(items []string, opts Options) (int, error) {
+	final, err := tea.NewProgram(newTUIModel(items, opts), tea.WithAltScreen()).Run()
+	if err != nil {
+		return 0, fmt.Errorf("ファインダーの表示に失敗しました: %w", err)
+	}
+	return final.(tuiModel).result()
+}
+
+// スタイル定義
+var (
+	tuiHeaderStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("170"))
+
+	tuiSelectedStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("170")).
+				Bold(true)
+
+	tuiCountStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241"))
+
+	tuiHelpStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241"))
+)
+
+// tuiState はファインダーの終了状態を表します。
+type tuiState int
+
+const (
+	tuiRunning tuiState = iota
+	tuiSelected
+	tuiBack
+	tuiAborted
+)
+
+// tuiModel は TUIFinder の状態を保持します。
+type tuiModel struct {
+	items   []string
+	header  string
+	back    bool // 検索語が空の状態の Backspace で上の階層に戻る
+	query   []rune
+	matches []int // query に一致する items の位置
+	cursor  int   // matches 内の位置
+	offset  int   // 表示を開始する matches 内の位置
+	height  int   // 端末の高さ
+	width   int   // 端末の幅
+	state   tuiState
+}
+
+// newTUIModel は items を候補とするモデルを作成します。
+func newTUIModel(items []string, opts Options) tuiModel {
+	m := tuiModel{items: items, header: opts.Header, back: opts.Back, query: []rune(opts.Query), height: 24, width: 80}
+	m.filter()
+	return m
+}
+
+// result は終了状態に応じた Find の戻り値を返します。
+func (m tuiModel) result() (int, error) {
+	switch m.state {
+	case tuiSelected:
+		return m.matches[m.cursor], nil
+	case tuiBack:
+		return 0, ErrBack
+	default:
+		return 0, fuzzyfinder.ErrAbort
+	}
+}
+
+// Init は初期化時に実行されるコマンドを返します。
+func (m tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update はメッセージに応じて状態を更新します。
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height, m.width = msg.Height, msg.Width
+		m.scroll()
+
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			m.state = tuiAborted
+			return m, tea.Quit
+
+		case tea.KeyEnter:
+			if len(m.matches) > 0 {
+				m.state = tuiSelected
+				return m, tea.Quit
+			}
+
+		case tea.KeyBackspace, tea.KeyCtrlH:
+			if len(m.query) == 0 {
+				if m.back {
+					m.state = tuiBack
+					return m, tea.Quit
+				}
+				break
+			}
+			m.query = m.query[:len(m.query)-1]
+			m.filter()
+
+		case tea.KeyCtrlU:
+			m.query = nil
+			m.filter()
+
+		case tea.KeyUp, tea.KeyCtrlP, tea.KeyCtrlK:
+			if m.cursor > 0 {
+				m.cursor--
+				m.scroll()
+			}
+
+		case tea.KeyDown, tea.KeyCtrlN, tea.KeyCtrlJ:
+			if m.cursor < len(m.matches)-1 {
+				m.cursor++
+				m.scroll()
+			}
+
+		case tea.KeySpace:
+			m.query = append(m.query, ' ')
+			m.filter()
+
+		case tea.KeyRunes:
+			m.query = append(m.query, msg.Runes...)
+			m.filter()
+		}
+	}
+
+	return m, nil
+}
+
+// filter は検索語に一致する候補を絞り込み、カーソルを先頭に戻します。
+// 検索語をそのまま含む候補を先に、それ以外は元の順序で並べます。
+func (m *tuiModel) filter() {
+	terms := strings.Fields(strings.ToLower(string(m.query)))
+	var exact, fuzzy []int
+	for i, item := range m.items {
+		switch match(strings.ToLower(item), terms) {
+		case matchExact:
+			exact = append(exact, i)
+		case matchFuzzy:
+			fuzzy = append(fuzzy, i)
+		}
+	}
+	m.matches = append(exact, fuzzy...)
+	m.cursor, m.offset = 0, 0
+}
+
+// matchKind は候補が検索語にどのように一致したかを表します。
+type matchKind int
+
+const (
+	matchNone matchKind = iota
+	matchFuzzy
+	matchExact
+)
+
+// match は小文字に揃えた item がすべての検索語に一致するかを返します。
+// すべての検索語をそのまま含む場合は matchExact、
+// 検索語の文字がこの順に含まれているだけの場合は matchFuzzy になります。
+func match(item string, terms []string) matchKind {
+	kind := matchExact
+	for _, term := range terms {
+		if strings.Contains(item, term) {
+			continue
+		}
+		if !subsequence(item, term) {
+			return matchNone
+		}
+		kind = matchFuzzy
+	}
+	return kind
+}
+
+// subsequence は term の文字が item にこの順で含まれているかを返します。
+func subsequence(item, term string) bool {
+	t := []rune(term)
+	i := 0
+	for _, r := range item {
+		if i < len(t) && r == t[i] {
+			i++
+		}
+	}
+	return i == len(t)
+}
+
+// listHeight は候補を表示できる行数を返します。
+func (m tuiModel) listHeight() int {
+	// 見出し、検索語、件数、操作説明の行を除く
+	return max(m.height-4, 1)
+}
+
+// scroll はカーソルが表示範囲に入るように表示開始位置を調整します。
+func (m *tuiModel) scroll() {
+	h := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+h {
+		m.offset = m.cursor - h + 1
+	}
+}
+
+// View は画面に表示する内容を返します。
+func (m tuiModel) View() string {
+	if m.state != tuiRunning {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(tuiHeaderStyle.Render(m.header))
+	sb.WriteString("\n")
+	sb.WriteString("> " + string(m.query) + "\n")
+	sb.WriteString(tuiCountStyle.Render(fmt.Sprintf("  %d/%d", len(m.matches), len(m.items))))
+	sb.WriteString("\n")
+
+	end := min(m.offset+m.listHeight(), len(m.matches))
+	for i := m.offset; i < end; i++ {
+		line := runewidth.Truncate(m.items[m.matches[i]], max(m.width-2, 1), "…")
+		if i == m.cursor {
+			sb.WriteString(tuiSelectedStyle.Render("> " + line))
+		} else {
+			sb.WriteString("  " + line)
+		}
+		sb.WriteString("\n")
+	}
+
+	help := "Enter: 選択, Esc: キャンセル"
+	if m.back {
+		help = "Enter: 選択, BS（検索語が空のとき）: 上の階層へ, Esc: キャンセル"
+	}
+	sb.WriteString(tuiHelpStyle.Render(help))
+	return sb.String()
+}
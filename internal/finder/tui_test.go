@@ -0,0 +1,203 @@
+package finder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ktr0731/go-fuzzyfinder"
+)
+
+func newTestModel() tuiModel {
+	return newTUIModel([]string{
+		`work  C:\Work`,
+		`docs  C:\Users\Test\Documents`,
+		`wiki  D:\Wiki`,
+	}, Options{Header: "ブックマーク"})
+}
+
+// typeKeys は文字列を1文字ずつ入力します。
+func typeKeys(m tuiModel, s string) tuiModel {
+	for _, r := range s {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+		if r == ' ' {
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{r}}
+		}
+		result, _ := m.Update(msg)
+		m = result.(tuiModel)
+	}
+	return m
+}
+
+// press はキーを1回押します。
+func press(m tuiModel, key tea.KeyType) tuiModel {
+	result, _ := m.Update(tea.KeyMsg{Type: key})
+	return result.(tuiModel)
+}
+
+func TestTUIModel_Filter(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected []int
+	}{
+		{"", []int{0, 1, 2}},
+		{"w", []int{0, 2}},
+		{"wiki", []int{2}},
+		{"WORK", []int{0}},
+		{"wk", []int{0, 2}},     // 文字がこの順に含まれていれば一致
+		{"doc users", []int{1}}, // 空白で区切った検索語はすべてに一致する必要がある
+		{"none", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			m := typeKeys(newTestModel(), tt.query)
+			if !reflect.DeepEqual(m.matches, tt.expected) {
+				t.Errorf("期待: %v, 取得: %v", tt.expected, m.matches)
+			}
+		})
+	}
+}
+
+func TestTUIModel_FilterOrder(t *testing.T) {
+	// 検索語をそのまま含む候補を先に並べる
+	m := newTUIModel([]string{`a-b-c`, `xabc`, `b`, `abc`}, Options{})
+	m = typeKeys(m, "abc")
+
+	expected := []int{1, 3, 0}
+	if !reflect.DeepEqual(m.matches, expected) {
+		t.Errorf("期待: %v, 取得: %v", expected, m.matches)
+	}
+}
+
+func TestTUIModel_InitialQuery(t *testing.T) {
+	m := newTUIModel([]string{"work", "wiki", "docs"}, Options{Query: "wi"})
+
+	if !reflect.DeepEqual(m.matches, []int{1}) {
+		t.Errorf("期待: %v, 取得: %v", []int{1}, m.matches)
+	}
+
+	// 初期値の検索語も Backspace で削除できる
+	m = press(press(m, tea.KeyBackspace), tea.KeyBackspace)
+	if len(m.matches) != 3 || m.state != tuiRunning {
+		t.Errorf("期待: 3件で継続, 取得: %d件 (state=%d)", len(m.matches), m.state)
+	}
+}
+
+func TestTUIModel_Select(t *testing.T) {
+	m := newTestModel()
+	m = press(m, tea.KeyDown)
+	m = press(m, tea.KeyDown)
+	m = press(m, tea.KeyDown) // 末尾より先には進まない
+	m = press(m, tea.KeyUp)
+	m = press(m, tea.KeyEnter)
+
+	idx, err := m.result()
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if idx != 1 {
+		t.Errorf("期待: 1, 取得: %d", idx)
+	}
+}
+
+func TestTUIModel_SelectFiltered(t *testing.T) {
+	m := typeKeys(newTestModel(), "wiki")
+	m = press(m, tea.KeyEnter)
+
+	idx, err := m.result()
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if idx != 2 {
+		t.Errorf("期待: 2, 取得: %d", idx)
+	}
+}
+
+func TestTUIModel_EnterWithoutMatches(t *testing.T) {
+	m := typeKeys(newTestModel(), "none")
+	m = press(m, tea.KeyEnter)
+
+	if m.state != tuiRunning {
+		t.Error("候補がない場合は Enter で終了するべきではありません")
+	}
+}
+
+func TestTUIModel_Backspace(t *testing.T) {
+	tests := []struct {
+		name      string
+		back      bool
+		expectErr error // 検索語が空の状態で Backspace を押した後の結果（nil の場合は終了しない）
+	}{
+		{"階層をたどる場合は上の階層に戻る", true, ErrBack},
+		{"階層をたどらない場合は何もしない", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTUIModel([]string{`work  C:\Work`, `wiki  D:\Wiki`}, Options{Back: tt.back})
+			m = typeKeys(m, "wi")
+
+			// 検索語がある間は1文字ずつ削除する
+			m = press(m, tea.KeyBackspace)
+			if string(m.query) != "w" || m.state != tuiRunning {
+				t.Fatalf("期待: 検索語 %q で継続, 取得: %q (state=%d)", "w", string(m.query), m.state)
+			}
+			m = press(m, tea.KeyBackspace)
+			if m.state != tuiRunning {
+				t.Fatal("検索語を消しただけでは終了するべきではありません")
+			}
+
+			// 上の階層に戻れる場合だけ操作を案内する
+			if got := strings.Contains(m.View(), "上の階層へ"); got != tt.back {
+				t.Errorf("Backspace の案内 期待: %v, 取得: %v", tt.back, got)
+			}
+
+			m = press(m, tea.KeyBackspace)
+			if tt.expectErr == nil {
+				if m.state != tuiRunning {
+					t.Errorf("終了するべきではありません (state=%d)", m.state)
+				}
+				return
+			}
+			if _, err := m.result(); !errors.Is(err, tt.expectErr) {
+				t.Errorf("期待: %v, 取得: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestTUIModel_Abort(t *testing.T) {
+	for _, key := range []tea.KeyType{tea.KeyEsc, tea.KeyCtrlC} {
+		m := press(newTestModel(), key)
+		if _, err := m.result(); !errors.Is(err, fuzzyfinder.ErrAbort) {
+			t.Errorf("期待: ErrAbort, 取得: %v", err)
+		}
+	}
+}
+
+func TestTUIModel_Scroll(t *testing.T) {
+	items := make([]string, 20)
+	for i := range items {
+		items[i] = string(rune('a' + i))
+	}
+	m := newTUIModel(items, Options{})
+	result, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 9}) // 候補は5行
+	m = result.(tuiModel)
+
+	for range 7 {
+		m = press(m, tea.KeyDown)
+	}
+	if m.cursor != 7 || m.offset != 3 {
+		t.Errorf("期待: cursor=7 offset=3, 取得: cursor=%d offset=%d", m.cursor, m.offset)
+	}
+
+	for range 5 {
+		m = press(m, tea.KeyUp)
+	}
+	if m.cursor != 2 || m.offset != 2 {
+		t.Errorf("期待: cursor=2 offset=2, 取得: cursor=%d offset=%d", m.cursor, m.offset)
+	}
+}
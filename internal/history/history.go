@@ -0,0 +1,223 @@
+// Package history はあふwで見かけた・移動したディレクトリをディスクに記録します。
+//
+// あふwの履歴は窓ごとに少数しか保持されないため、ここに蓄積しておくことで
+// あふwの履歴から消えたディレクトリも検索できるようにします。
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/fileutil"
+	"github.com/tana9/afxw-tools/internal/winpath"
+)
+
+// Entry は記録されたディレクトリを表します。
+type Entry struct {
+	Path      string      `json:"path"`
+	Count     int         `json:"count"`               // 移動した回数
+	FirstSeen time.Time   `json:"first_seen"`          // 初めて記録した日時
+	LastSeen  time.Time   `json:"last_seen"`           // 最後に履歴で見かけた、または移動した日時
+	LastVisit time.Time   `json:"last_visit,omitzero"` // 最後に移動した日時
+	Visits    []time.Time `json:"visits,omitempty"`    // 直近の移動日時（古い順、最大 MaxVisits 件）
+}
+
+// MaxVisits は1エントリあたりに保持する移動日時の最大件数です。
+const MaxVisits = 100
+
+// MaxEntries は履歴ファイルに保持するエントリの最大件数です。
+const MaxEntries = 2000
+
+// Store は記録されたディレクトリの集合です。
+type Store struct {
+	entries []Entry
+	index   map[string]int
+}
+
+// file は履歴ファイルの形式です。
+type file struct {
+	Entries []Entry `json:"entries"`
+}
+
+// New は空のストアを作成します。
+func New() *Store {
+	return &Store{index: make(map[string]int)}
+}
+
+// DefaultPath は履歴ファイルのデフォルトパスを返します。
+// Windows では %APPDATA%\afxw-tools\history.json になります。
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "afxw-tools", "history.json"), nil
+}
+
+// Load は指定されたファイルから履歴を読み込みます。
+// ファイルが存在しない場合は空のストアを返します。
+func Load(path string) (*Store, error) {
+	s := New()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("履歴ファイルの読み込みに失敗しました: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("履歴ファイルの解析に失敗しました: %w", err)
+	}
+	for _, e := range f.Entries {
+		if _, ok := s.index[winpath.Key(e.Path)]; ok {
+			continue
+		}
+		s.index[winpath.Key(e.Path)] = len(s.entries)
+		s.entries = append(s.entries, e)
+	}
+	return s, nil
+}
+
+// Save は履歴を指定されたファイルに書き込みます。
+// エントリが MaxEntries を超えている場合は、最後に見かけた日時の古いものから取り除きます。
+func (s *Store) Save(path string) error {
+	s.prune()
+	data, err := json.MarshalIndent(file{Entries: s.entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("履歴のエンコードに失敗しました: %w", err)
+	}
+	if err := fileutil.WriteAtomic(path, data); err != nil {
+		return fmt.Errorf("履歴ファイルの書き込みに失敗しました: %w", err)
+	}
+	return nil
+}
+
+// Update は履歴を読み込み、fn で更新してから保存します。更新後のストアを返します。
+// 読み込みから保存までロックを保持するため、複数のプロセスから同時に呼び出しても更新が失われません。
+func Update(path string, fn func(s *Store)) (*Store, error) {
+	var s *Store
+	err := fileutil.WithLock(path, func() error {
+		var err error
+		if s, err = Load(path); err != nil {
+			return err
+		}
+		fn(s)
+		return s.Save(path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// prune はエントリが MaxEntries を超えている場合に、最後に見かけた日時の古いものから取り除きます。
+// 残したエントリの順序は変えません。
+func (s *Store) prune() {
+	if len(s.entries) <= MaxEntries {
+		return
+	}
+	cutoff := s.Entries()[MaxEntries-1].LastSeen
+	// 最後に見かけた日時が境界と同じエントリは、上限に収まる件数だけ先に記録されたものから残す
+	ties := MaxEntries
+	for _, e := range s.entries {
+		if e.LastSeen.After(cutoff) {
+			ties--
+		}
+	}
+	entries := s.entries[:0]
+	for _, e := range s.entries {
+		if e.LastSeen.Before(cutoff) {
+			continue
+		}
+		if e.LastSeen.Equal(cutoff) {
+			if ties == 0 {
+				continue
+			}
+			ties--
+		}
+		entries = append(entries, e)
+	}
+	s.entries = entries
+	s.index = make(map[string]int, len(s.entries))
+	for i, e := range s.entries {
+		s.index[winpath.Key(e.Path)] = i
+	}
+}
+
+// Seen はあふwの履歴で見かけたディレクトリを記録します。移動回数は増やしません。
+func (s *Store) Seen(paths []string, now time.Time) {
+	for _, path := range paths {
+		e := s.entry(path, now)
+		e.LastSeen = now
+	}
+}
+
+// Visit はディレクトリへの移動を記録し、移動回数を1増やします。
+func (s *Store) Visit(path string, now time.Time) {
+	e := s.entry(path, now)
+	e.Count++
+	e.LastSeen = now
+	e.LastVisit = now
+	e.Visits = append(e.Visits, now)
+	if len(e.Visits) > MaxVisits {
+		e.Visits = e.Visits[len(e.Visits)-MaxVisits:]
+	}
+}
+
+// entry は path のエントリを返します。存在しない場合は作成します。
+func (s *Store) entry(path string, now time.Time) *Entry {
+	if i, ok := s.index[winpath.Key(path)]; ok {
+		return &s.entries[i]
+	}
+	s.index[winpath.Key(path)] = len(s.entries)
+	s.entries = append(s.entries, Entry{Path: path, FirstSeen: now})
+	return &s.entries[len(s.entries)-1]
+}
+
+// Entries は記録されたエントリを最後に見かけた日時の新しい順で返します。
+func (s *Store) Entries() []Entry {
+	entries := append([]Entry(nil), s.entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastSeen.After(entries[j].LastSeen)
+	})
+	return entries
+}
+
+// Paths は記録されたパスを最後に見かけた日時の新しい順で返します。
+func (s *Store) Paths() []string {
+	entries := s.Entries()
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = e.Path
+	}
+	return paths
+}
+
+// Hook は移動先を path の履歴ファイルに記録する afx.JumpHook を返します。
+func Hook(path string) afx.JumpHook {
+	return func(ctx context.Context, dir string) error {
+		if _, err := Update(path, func(s *Store) { s.Visit(dir, time.Now()) }); err != nil {
+			return fmt.Errorf("履歴の記録に失敗しました: %w", err)
+		}
+		return nil
+	}
+}
+
+// Wrap はディレクトリ移動を既定の履歴ファイルに記録する AFX を返します。
+// 履歴ファイルのパスが決まらない場合は a をそのまま返します。
+func Wrap(a afx.AFX) afx.AFX {
+	path, err := DefaultPath()
+	if err != nil {
+		return a
+	}
+	return afx.WithJumpHook(a, Hook(path))
+}
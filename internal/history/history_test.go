@@ -0,0 +1,180 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+)
+
+func TestLoad_NonExistentFile(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "history.json"))
+	if err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if len(s.Entries()) != 0 {
+		t.Errorf("期待: 空, 取得: %v", s.Entries())
+	}
+}
+
+func TestLoad_Broken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	if err := os.WriteFile(path, []byte("{broken"), 0644); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Error("エラーが期待されましたが、nilが返りました")
+	}
+}
+
+func TestSeenAndVisit(t *testing.T) {
+	s, _ := Load(filepath.Join(t.TempDir(), "history.json"))
+	t1 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	s.Seen([]string{`C:\A`, `C:\B`}, t1)
+	s.Visit(`c:\a\`, t2)
+	s.Visit(`C:\A`, t2)
+
+	entries := s.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("期待: 2件, 取得: %d件", len(entries))
+	}
+
+	a := entries[0]
+	if a.Path != `C:\A` || a.Count != 2 || !a.FirstSeen.Equal(t1) || !a.LastVisit.Equal(t2) {
+		t.Errorf("予期しないエントリ: %+v", a)
+	}
+	b := entries[1]
+	if b.Path != `C:\B` || b.Count != 0 || !b.LastVisit.IsZero() {
+		t.Errorf("予期しないエントリ: %+v", b)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "history.json")
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s, _ := Load(path)
+	s.Seen([]string{`C:\Old`}, now)
+	s.Visit(`C:\New`, now.Add(time.Minute))
+	if err := s.Save(path); err != nil {
+		t.Fatalf("保存に失敗しました: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Paths(), []string{`C:\New`, `C:\Old`}) {
+		t.Errorf("予期しないパス: %q", loaded.Paths())
+	}
+
+	// 一時ファイルが残っていないこと
+	files, _ := os.ReadDir(filepath.Dir(path))
+	if len(files) != 1 {
+		t.Errorf("履歴ファイル以外のファイルが残っています: %v", files)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	now := time.Now()
+
+	for range 3 {
+		if _, err := Update(path, func(s *Store) { s.Visit(`C:\Work`, now) }); err != nil {
+			t.Fatalf("更新に失敗しました: %v", err)
+		}
+	}
+
+	s, _ := Load(path)
+	if got := s.Entries()[0].Count; got != 3 {
+		t.Errorf("期待: 3回, 取得: %d回", got)
+	}
+}
+
+func TestUpdate_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	now := time.Now()
+
+	// 同時に更新しても移動の記録が失われない
+	const workers, perWorker = 8, 10
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for range perWorker {
+				if _, err := Update(path, func(s *Store) { s.Visit(`C:\Work`, now) }); err != nil {
+					t.Errorf("更新に失敗しました: %v", err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if got := s.Entries()[0].Count; got != workers*perWorker {
+		t.Errorf("期待: %d回, 取得: %d回", workers*perWorker, got)
+	}
+}
+
+func TestSave_Prune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s := New()
+	for i := range MaxEntries + 10 {
+		// 先頭の20件は同じ日時で、そのうち10件だけが上限に収まる
+		seen := base.Add(time.Duration(max(i-19, 0)) * time.Minute)
+		s.Seen([]string{fmt.Sprintf(`C:\Dir%d`, i)}, seen)
+	}
+	if err := s.Save(path); err != nil {
+		t.Fatalf("保存に失敗しました: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	paths := loaded.Paths()
+	if len(paths) != MaxEntries {
+		t.Fatalf("期待: %d件, 取得: %d件", MaxEntries, len(paths))
+	}
+	// 最後に見かけた日時の古いものから取り除き、同じ日時なら先に記録されたものを残す
+	if paths[0] != fmt.Sprintf(`C:\Dir%d`, MaxEntries+9) || paths[len(paths)-1] != `C:\Dir9` {
+		t.Errorf("予期しないエントリが残っています: 先頭 %s, 末尾 %s", paths[0], paths[len(paths)-1])
+	}
+	for _, removed := range []string{`C:\Dir10`, `C:\Dir19`} {
+		if slices.Contains(paths, removed) {
+			t.Errorf("%s は取り除かれるべきです", removed)
+		}
+	}
+}
+
+func TestHook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	a := afx.WithJumpHook(&afxtest.MockAFX{}, Hook(path))
+
+	if err := a.EXCD(t.Context(), `C:\Work`); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if err := a.EXCDOpposite(t.Context(), `C:\Work`); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	s, _ := Load(path)
+	entries := s.Entries()
+	if len(entries) != 1 || entries[0].Count != 2 {
+		t.Errorf("予期しないエントリ: %+v", entries)
+	}
+}
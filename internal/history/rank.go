@@ -0,0 +1,103 @@
+package history
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tana9/afxw-tools/internal/winpath"
+)
+
+// SortMode は候補の並び順を表します。
+type SortMode string
+
+const (
+	// SortHistory はあふwの履歴の順に並べます。
+	SortHistory SortMode = "history"
+	// SortFrecency は移動回数と最近さを組み合わせたスコアの高い順に並べます。
+	SortFrecency SortMode = "frecency"
+	// SortRecent は最後に移動した日時の新しい順に並べます。
+	SortRecent SortMode = "recent"
+	// SortAlpha はパスの辞書順に並べます。
+	SortAlpha SortMode = "alpha"
+)
+
+// DefaultHalfLife は frecency で移動の重みが半分になるまでの既定の期間です。
+const DefaultHalfLife = 7 * 24 * time.Hour
+
+// ParseSortMode は文字列を SortMode に変換します。
+func ParseSortMode(s string) (SortMode, error) {
+	switch mode := SortMode(s); mode {
+	case SortHistory, SortFrecency, SortRecent, SortAlpha:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("無効な並び順: %s", s)
+	}
+}
+
+// Frecency は now 時点でのエントリのスコアを返します。
+// 1回の移動ごとに1点を加え、その重みは halfLife 経過するごとに半分になります。
+func (e Entry) Frecency(now time.Time, halfLife time.Duration) float64 {
+	visits := e.Visits
+	if len(visits) == 0 && e.Count > 0 {
+		// 移動日時を記録する前のエントリは、すべて最後の移動日時に移動したものとみなす
+		visits = make([]time.Time, e.Count)
+		for i := range visits {
+			visits[i] = e.LastVisit
+		}
+	}
+
+	var score float64
+	for _, v := range visits {
+		age := now.Sub(v)
+		if age < 0 {
+			age = 0
+		}
+		score += math.Exp2(-float64(age) / float64(halfLife))
+	}
+	return score
+}
+
+// Sort は paths を mode に従って並べ替えた新しいスライスを返します。
+// 同じ順位のパスは元の順序を保ちます。記録にないパスはスコア0、日時なしとして扱います。
+func (s *Store) Sort(paths []string, mode SortMode, now time.Time, halfLife time.Duration) []string {
+	sorted := append([]string(nil), paths...)
+
+	switch mode {
+	case SortFrecency:
+		scores := make(map[string]float64, len(paths))
+		for _, p := range paths {
+			if e := s.lookup(p); e != nil {
+				scores[p] = e.Frecency(now, halfLife)
+			}
+		}
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return scores[sorted[i]] > scores[sorted[j]]
+		})
+	case SortRecent:
+		visited := make(map[string]time.Time, len(paths))
+		for _, p := range paths {
+			if e := s.lookup(p); e != nil {
+				visited[p] = e.LastVisit
+			}
+		}
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return visited[sorted[i]].After(visited[sorted[j]])
+		})
+	case SortAlpha:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return strings.ToLower(sorted[i]) < strings.ToLower(sorted[j])
+		})
+	}
+	return sorted
+}
+
+// lookup は path のエントリを返します。記録にない場合は nil を返します。
+func (s *Store) lookup(path string) *Entry {
+	if i, ok := s.index[winpath.Key(path)]; ok {
+		return &s.entries[i]
+	}
+	return nil
+}
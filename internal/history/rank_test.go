@@ -0,0 +1,104 @@
+package history
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSortMode(t *testing.T) {
+	for _, s := range []string{"history", "frecency", "recent", "alpha"} {
+		if mode, err := ParseSortMode(s); err != nil || string(mode) != s {
+			t.Errorf("%s: 取得: %q, %v", s, mode, err)
+		}
+	}
+	if _, err := ParseSortMode("score"); err == nil {
+		t.Error("エラーが期待されましたが、nilが返りました")
+	}
+}
+
+func TestFrecency(t *testing.T) {
+	now := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	halfLife := 24 * time.Hour
+
+	tests := []struct {
+		name     string
+		entry    Entry
+		expected float64
+	}{
+		{"移動なし", Entry{}, 0},
+		{"直前の移動", Entry{Visits: []time.Time{now}}, 1},
+		{"半減期前の移動", Entry{Visits: []time.Time{now.Add(-halfLife)}}, 0.5},
+		{"複数の移動", Entry{Visits: []time.Time{now.Add(-2 * halfLife), now}}, 1.25},
+		{"未来の日時", Entry{Visits: []time.Time{now.Add(time.Hour)}}, 1},
+		{"移動日時のない古い形式", Entry{Count: 2, LastVisit: now.Add(-halfLife)}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.entry.Frecency(now, halfLife)
+			if math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("期待: %v, 取得: %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestVisit_KeepsRecentVisits(t *testing.T) {
+	s, _ := Load(filepath.Join(t.TempDir(), "history.json"))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range MaxVisits + 10 {
+		s.Visit(`C:\Work`, start.Add(time.Duration(i)*time.Minute))
+	}
+
+	e := s.Entries()[0]
+	if len(e.Visits) != MaxVisits {
+		t.Fatalf("期待: %d件, 取得: %d件", MaxVisits, len(e.Visits))
+	}
+	if !e.Visits[0].Equal(start.Add(10 * time.Minute)) {
+		t.Errorf("古い移動日時から削除されるべきです: %v", e.Visits[0])
+	}
+	if e.Count != MaxVisits+10 {
+		t.Errorf("移動回数は削除されるべきではありません: %d", e.Count)
+	}
+}
+
+func TestSort(t *testing.T) {
+	now := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	s, _ := Load(filepath.Join(t.TempDir(), "history.json"))
+
+	// Often: 古いが何度も移動, Recent: 最近1回だけ移動, Never: 記録なし
+	for i := range 5 {
+		s.Visit(`C:\Often`, now.Add(-72*time.Hour-time.Duration(i)*time.Minute))
+	}
+	s.Visit(`C:\Recent`, now.Add(-time.Hour))
+
+	paths := []string{`C:\never`, `C:\Recent`, `C:\Often`}
+
+	tests := []struct {
+		mode     SortMode
+		expected []string
+	}{
+		{SortHistory, []string{`C:\never`, `C:\Recent`, `C:\Often`}},
+		{SortFrecency, []string{`C:\Often`, `C:\Recent`, `C:\never`}},
+		{SortRecent, []string{`C:\Recent`, `C:\Often`, `C:\never`}},
+		{SortAlpha, []string{`C:\never`, `C:\Often`, `C:\Recent`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			got := s.Sort(paths, tt.mode, now, DefaultHalfLife)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+
+	// 半減期を短くすると最近の移動が優先される
+	got := s.Sort(paths, SortFrecency, now, time.Hour)
+	if got[0] != `C:\Recent` {
+		t.Errorf("短い半減期では C:\\Recent が先頭になるべきです: %q", got)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package singleinstance
+
+// acquire は Windows 以外では何もしません。
+// JSON-RPC ブリッジ経由でテストや開発を行う環境向けです。
+func acquire(name string, timeoutMs uint32) error {
+	return nil
+}
@@ -0,0 +1,41 @@
+package singleinstance
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// WaitForSingleObject の戻り値定数（uint32）
+const (
+	waitObject0   uint32 = 0x00000000
+	waitAbandoned uint32 = 0x00000080
+	waitTimeout   uint32 = 0x00000102
+)
+
+func acquire(name string, timeoutMs uint32) error {
+	h, err := windows.CreateMutex(nil, true, windows.StringToUTF16Ptr("Local\\"+name))
+	if err == nil {
+		// 新規作成成功 - プロセス終了まで保持（意図的なリーク）
+		_ = h
+		return nil
+	}
+	if err != windows.ERROR_ALREADY_EXISTS {
+		return fmt.Errorf("ミューテックスの作成に失敗しました: %w", err)
+	}
+
+	// 別インスタンスが起動中 - 終了を待つ
+	event, _ := windows.WaitForSingleObject(h, timeoutMs)
+	switch event {
+	case waitObject0, waitAbandoned:
+		// 前のインスタンスが終了した - h を保持し続ける
+		_ = h
+		return nil
+	case waitTimeout:
+		windows.CloseHandle(h)
+		return ErrTimeout
+	default:
+		windows.CloseHandle(h)
+		return fmt.Errorf("ミューテックスの待機に失敗しました")
+	}
+}
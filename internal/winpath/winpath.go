@@ -0,0 +1,252 @@
+// Package winpath は Windows のパスの規則を OS に依存せずに実装します。
+//
+// path/filepath は実行中の OS の規則に従うため、Linux 上のテストでは "C:\" などを正しく扱えません。
+// このパッケージはドライブレター、UNC パス、\\?\ 形式の長いパス、大文字小文字の同一視、
+// 区切り文字と末尾の区切りの正規化を常に Windows の規則で行います。
+package winpath
+
+import "strings"
+
+const (
+	// Separator は Windows のパス区切り文字です。
+	Separator = '\\'
+
+	longPrefix    = `\\?\`
+	longUNCPrefix = `\\?\UNC\`
+	devicePrefix  = `\\.\`
+)
+
+// isSlash は c がパス区切り文字（\ または /）かを返します。
+func isSlash(c byte) bool {
+	return c == '\\' || c == '/'
+}
+
+// toBackslash は / を \ に置き換えます。
+func toBackslash(path string) string {
+	return strings.ReplaceAll(path, "/", `\`)
+}
+
+// VolumeName はパスの先頭にあるボリューム名を返します。
+// 例: "C:"、`\\server\share`、`\\?\C:`、`\\?\UNC\server\share`。
+func VolumeName(path string) string {
+	return path[:volumeNameLen(path)]
+}
+
+// volumeNameLen はボリューム名の長さを返します。
+func volumeNameLen(path string) int {
+	if len(path) >= 2 && path[1] == ':' && isLetter(path[0]) {
+		return 2
+	}
+	if len(path) < 2 || !isSlash(path[0]) || !isSlash(path[1]) {
+		return 0
+	}
+
+	p := toBackslash(path)
+	switch {
+	case hasPrefixFold(p, longUNCPrefix):
+		return uncLen(p, len(longUNCPrefix))
+	case strings.HasPrefix(p, longPrefix), strings.HasPrefix(p, devicePrefix):
+		// \\?\C: や \\.\PhysicalDrive0 はプレフィックスの次の要素までがボリューム名
+		n := len(longPrefix)
+		for n < len(p) && p[n] != '\\' {
+			n++
+		}
+		return n
+	default:
+		return uncLen(p, 2)
+	}
+}
+
+// uncLen は start から始まる server\share 部分までの長さを返します。
+// share がない場合は UNC パスとみなさず0を返します。
+func uncLen(p string, start int) int {
+	i := strings.IndexByte(p[start:], '\\')
+	if i <= 0 {
+		return 0
+	}
+	shareStart := start + i + 1
+	j := strings.IndexByte(p[shareStart:], '\\')
+	if j < 0 {
+		j = len(p) - shareStart
+	}
+	if j == 0 {
+		return 0
+	}
+	return shareStart + j
+}
+
+// IsUNC はパスが UNC パス（\\server\share または \\?\UNC\server\share）かを返します。
+func IsUNC(path string) bool {
+	p := toBackslash(path)
+	if hasPrefixFold(p, longUNCPrefix) {
+		return volumeNameLen(p) > 0
+	}
+	if strings.HasPrefix(p, longPrefix) || strings.HasPrefix(p, devicePrefix) {
+		return false
+	}
+	return strings.HasPrefix(p, `\\`) && volumeNameLen(p) > 0
+}
+
+// IsAbs はパスが絶対パスかを返します。"C:foo" のようなドライブ相対パスは絶対パスではありません。
+func IsAbs(path string) bool {
+	n := volumeNameLen(path)
+	if n == 0 {
+		return false
+	}
+	if strings.HasPrefix(toBackslash(path), `\\`) {
+		return true
+	}
+	return n < len(path) && isSlash(path[n])
+}
+
+// Clean はパスを正規化します。
+// 区切り文字を \ に統一し、連続した区切り、"."、".." を取り除きます。
+// 末尾の区切りはルート（"C:\" や `\\server\share\`）を除いて取り除きます。
+// 大文字小文字はそのまま残します。
+func Clean(path string) string {
+	if path == "" {
+		return "."
+	}
+	path = toBackslash(path)
+	n := volumeNameLen(path)
+	vol, rest := path[:n], path[n:]
+	rooted := strings.HasPrefix(rest, `\`) || strings.HasPrefix(vol, `\\`)
+
+	var elems []string
+	for _, e := range strings.Split(rest, `\`) {
+		switch e {
+		case "", ".":
+		case "..":
+			if len(elems) > 0 && elems[len(elems)-1] != ".." {
+				elems = elems[:len(elems)-1]
+			} else if !rooted {
+				elems = append(elems, "..")
+			}
+		default:
+			elems = append(elems, e)
+		}
+	}
+
+	joined := strings.Join(elems, `\`)
+	switch {
+	case rooted:
+		return vol + `\` + joined
+	case vol == "" && joined == "":
+		return "."
+	default:
+		return vol + joined
+	}
+}
+
+// Key はパスを比較用のキーに変換します。
+// Clean した上で \\?\ 形式を通常の形式に戻し、末尾の区切りを取り除いて大文字に揃えます。
+// Key が等しい2つのパスは Windows 上で同じ場所を指します。
+func Key(path string) string {
+	p := toBackslash(path)
+	switch {
+	case hasPrefixFold(p, longUNCPrefix):
+		p = `\\` + p[len(longUNCPrefix):]
+	case strings.HasPrefix(p, longPrefix) && volumeNameLen(p[len(longPrefix):]) == 2:
+		p = p[len(longPrefix):]
+	}
+	p = strings.TrimRight(Clean(p), `\`)
+	return strings.ToUpper(p)
+}
+
+// Equal は2つのパスが Windows 上で同じ場所を指すかを返します。
+func Equal(a, b string) bool {
+	return Key(a) == Key(b)
+}
+
+// Dedup は Windows 上で同じ場所を指すパスを取り除いたスライスを返します。
+// 最初に現れたものの表記と出現順序を保持します。
+func Dedup(paths []string) []string {
+	seen := make(map[string]bool, len(paths))
+	result := make([]string, 0, len(paths))
+	for _, p := range paths {
+		k := Key(p)
+		if !seen[k] {
+			seen[k] = true
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Join はパスの要素を \ で連結して Clean します。空の要素は無視します。
+func Join(elem ...string) string {
+	var parts []string
+	for _, e := range elem {
+		if e != "" {
+			parts = append(parts, e)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	// "C:" と "foo" は "C:foo"（ドライブ相対）として連結する
+	if len(parts) > 1 && len(parts[0]) == 2 && volumeNameLen(parts[0]) == 2 {
+		return Clean(parts[0] + strings.Join(parts[1:], `\`))
+	}
+	return Clean(strings.Join(parts, `\`))
+}
+
+// Abs は path を base を基準にした絶対パスにして Clean します。base は絶対パスを指定します。
+// path が "\foo" のようにルートから始まる場合は base のボリュームのルート、
+// "D:foo" のようなドライブ相対パスの場合は base と同じドライブなら base、違えばそのドライブのルートを基準にします。
+func Abs(base, path string) string {
+	if IsAbs(path) {
+		return Clean(path)
+	}
+	if n := volumeNameLen(path); n > 0 {
+		vol, rest := path[:n], path[n:]
+		if strings.EqualFold(vol, VolumeName(base)) {
+			return Join(base, rest)
+		}
+		return Join(vol+`\`, rest)
+	}
+	if len(path) > 0 && isSlash(path[0]) {
+		return Join(VolumeName(base)+`\`, path)
+	}
+	return Join(base, path)
+}
+
+// Base はパスの最後の要素を返します。末尾の区切りは無視します。
+// ルートのみのパスの場合は区切り文字を返します。
+func Base(path string) string {
+	path = toBackslash(path)
+	path = path[volumeNameLen(path):]
+	path = strings.TrimRight(path, `\`)
+	if path == "" {
+		return `\`
+	}
+	if i := strings.LastIndexByte(path, '\\'); i >= 0 {
+		path = path[i+1:]
+	}
+	return path
+}
+
+// Dir はパスの最後の要素を除いた部分を Clean して返します。
+func Dir(path string) string {
+	path = toBackslash(path)
+	n := volumeNameLen(path)
+	i := len(path) - 1
+	for i >= n && path[i] != '\\' {
+		i--
+	}
+	dir := Clean(path[n : i+1])
+	if dir == "." && n > 0 {
+		return Clean(path[:n])
+	}
+	return Clean(path[:n] + dir)
+}
+
+// isLetter は c が ASCII の英字かを返します。
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// hasPrefixFold は大文字小文字を区別せずに前方一致を判定します。
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
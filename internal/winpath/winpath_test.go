@@ -0,0 +1,268 @@
+package winpath
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVolumeName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`C:\Users\Test`, `C:`},
+		{`d:\work`, `d:`},
+		{`C:`, `C:`},
+		{`C:foo`, `C:`},
+		{`\\server\share\dir`, `\\server\share`},
+		{`\\server\share`, `\\server\share`},
+		{`//server/share/dir`, `//server/share`},
+		{`\\server`, ``},
+		{`\\server\`, ``},
+		{`\\?\C:\Users`, `\\?\C:`},
+		{`\\?\UNC\server\share\dir`, `\\?\UNC\server\share`},
+		{`\\.\PhysicalDrive0`, `\\.\PhysicalDrive0`},
+		{`relative\path`, ``},
+		{`\rooted`, ``},
+		{``, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := VolumeName(tt.input); got != tt.expected {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestIsUNC(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`\\server\share`, true},
+		{`\\server\share\dir`, true},
+		{`//server/share`, true},
+		{`\\?\UNC\server\share`, true},
+		{`\\server`, false},
+		{`\\?\C:\Users`, false},
+		{`C:\Users`, false},
+		{`relative`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := IsUNC(tt.input); got != tt.expected {
+				t.Errorf("期待: %v, 取得: %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestIsAbs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`C:\Users`, true},
+		{`C:/Users`, true},
+		{`C:\`, true},
+		{`C:`, false},
+		{`C:foo`, false},
+		{`\\server\share`, true},
+		{`\\?\C:\Users`, true},
+		{`\Users`, false},
+		{`Users`, false},
+		{``, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := IsAbs(tt.input); got != tt.expected {
+				t.Errorf("期待: %v, 取得: %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{``, `.`},
+		{`C:\Users\Test`, `C:\Users\Test`},
+		{`C:\Users\Test\`, `C:\Users\Test`},
+		{`C:/Users/Test/`, `C:\Users\Test`},
+		{`C:\\Users\\\Test`, `C:\Users\Test`},
+		{`C:\Users\.\Test\..\Other`, `C:\Users\Other`},
+		{`C:\..\Users`, `C:\Users`},
+		{`C:\`, `C:\`},
+		{`C:`, `C:`},
+		{`C:foo\..\..\bar`, `C:..\bar`},
+		{`\\server\share`, `\\server\share\`},
+		{`\\server\share\dir\`, `\\server\share\dir`},
+		{`\\server\share\..`, `\\server\share\`},
+		{`\\?\C:\Users\Test\`, `\\?\C:\Users\Test`},
+		{`\\?\UNC\server\share\dir`, `\\?\UNC\server\share\dir`},
+		{`relative\.\path\`, `relative\path`},
+		{`..\a\..\..\b`, `..\..\b`},
+		{`\rooted\..`, `\`},
+		{`.`, `.`},
+		{`C:\ユーザー\テスト\`, `C:\ユーザー\テスト`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Clean(tt.input); got != tt.expected {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{`C:\Users\Test`, `C:\Users\Test`, true},
+		{`C:\Users\Test`, `c:\users\test\`, true},
+		{`C:\Users\Test`, `C:/Users/Test`, true},
+		{`C:\Users\Test`, `C:\Users\Other`, false},
+		{`C:\`, `C:`, true},
+		{`C:\Users\Test`, `\\?\C:\Users\Test`, true},
+		{`\\server\share\dir`, `\\?\UNC\SERVER\share\dir\`, true},
+		{`\\server\share`, `\\server\share\`, true},
+		{`C:\Users\..\Windows`, `C:\Windows`, true},
+		{`C:\Users`, `D:\Users`, false},
+		{`C:\ドキュメント`, `c:\ドキュメント\`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"|"+tt.b, func(t *testing.T) {
+			if got := Equal(tt.a, tt.b); got != tt.expected {
+				t.Errorf("期待: %v, 取得: %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDedup(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected []string
+	}{
+		{
+			name:     "重複なし",
+			input:    []string{`C:\dir1`, `C:\dir2`, `C:\dir3`},
+			expected: []string{`C:\dir1`, `C:\dir2`, `C:\dir3`},
+		},
+		{
+			name:     "重複あり",
+			input:    []string{`C:\dir1`, `C:\dir2`, `C:\dir1`, `C:\dir3`, `C:\dir2`},
+			expected: []string{`C:\dir1`, `C:\dir2`, `C:\dir3`},
+		},
+		{
+			name:     "大文字小文字と末尾の区切りの違い",
+			input:    []string{`C:\Work`, `c:\work\`, `C:/WORK`, `\\?\C:\work`},
+			expected: []string{`C:\Work`},
+		},
+		{
+			name:     "すべて重複",
+			input:    []string{`C:\Windows`, `C:\Windows`, `C:\Windows`},
+			expected: []string{`C:\Windows`},
+		},
+		{
+			name:     "空スライス",
+			input:    []string{},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Dedup(tt.input); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		input    []string
+		expected string
+	}{
+		{[]string{`C:\Users`, `Test`}, `C:\Users\Test`},
+		{[]string{`C:\Users\`, `\Test\`}, `C:\Users\Test`},
+		{[]string{`C:`, `Users`}, `C:Users`},
+		{[]string{`\\server\share`, `dir`}, `\\server\share\dir`},
+		{[]string{``, `dir`}, `dir`},
+		{[]string{}, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(Join(tt.input...), func(t *testing.T) {
+			if got := Join(tt.input...); got != tt.expected {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAbs(t *testing.T) {
+	tests := []struct {
+		base     string
+		path     string
+		expected string
+	}{
+		{`C:\Work`, `D:\Data\`, `D:\Data`},
+		{`C:\Work`, `src\app`, `C:\Work\src\app`},
+		{`C:\Work`, `..\Docs`, `C:\Docs`},
+		{`C:\Work`, `.`, `C:\Work`},
+		{`C:\Work`, `\Temp`, `C:\Temp`},
+		{`C:\Work`, `c:src`, `C:\Work\src`},
+		{`C:\Work`, `D:src`, `D:\src`},
+		{`\\server\share\dir`, `sub`, `\\server\share\dir\sub`},
+		{`\\server\share\dir`, `\top`, `\\server\share\top`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.base+"+"+tt.path, func(t *testing.T) {
+			if got := Abs(tt.base, tt.path); got != tt.expected {
+				t.Errorf("期待: %q, 取得: %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBaseDir(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedBase string
+		expectedDir  string
+	}{
+		{`C:\Users\Test`, `Test`, `C:\Users`},
+		{`C:\Users\Test\`, `Test`, `C:\Users\Test`},
+		{`C:\Users`, `Users`, `C:\`},
+		{`C:\`, `\`, `C:\`},
+		{`C:foo`, `foo`, `C:`},
+		{`\\server\share\dir`, `dir`, `\\server\share\`},
+		{`dir\file.txt`, `file.txt`, `dir`},
+		{`file.txt`, `file.txt`, `.`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Base(tt.input); got != tt.expectedBase {
+				t.Errorf("Base 期待: %q, 取得: %q", tt.expectedBase, got)
+			}
+			if got := Dir(tt.input); got != tt.expectedDir {
+				t.Errorf("Dir 期待: %q, 取得: %q", tt.expectedDir, got)
+			}
+		})
+	}
+}
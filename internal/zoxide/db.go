@@ -0,0 +1,209 @@
+package zoxide
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DBFileName はzoxideのデータベースファイルの名前です。
+	DBFileName = "db.zo"
+	// EnvDataDir はzoxideのデータベースを置くディレクトリを指定する環境変数です。
+	EnvDataDir = "_ZO_DATA_DIR"
+
+	// dbVersion は対応しているデータベースの形式のバージョンです。
+	dbVersion = 3
+)
+
+const (
+	hour = time.Hour
+	day  = 24 * hour
+	week = 7 * day
+)
+
+// ErrUnsupportedVersion はデータベースの形式のバージョンに対応していないことを表します。
+var ErrUnsupportedVersion = errors.New("対応していないzoxideデータベースのバージョンです")
+
+// Dir はzoxideのデータベースに記録されたディレクトリを表します。
+type Dir struct {
+	Path         string    // ディレクトリパス
+	Rank         float64   // 移動回数に応じて増えるランク
+	LastAccessed time.Time // 最後に移動した日時（秒単位）
+}
+
+// Score は now の時点での frecency スコアを返します。
+// zoxide と同じく、最後に移動してからの経過時間に応じてランクに重みを掛けます。
+func (d Dir) Score(now time.Time) float64 {
+	elapsed := max(now.Sub(d.LastAccessed), 0)
+	switch {
+	case elapsed < hour:
+		return d.Rank * 4
+	case elapsed < day:
+		return d.Rank * 2
+	case elapsed < week:
+		return d.Rank * 0.5
+	default:
+		return d.Rank * 0.25
+	}
+}
+
+// DBPath はzoxideのデータベースファイルのパスを返します。
+// 環境変数 _ZO_DATA_DIR が設定されていればその中の db.zo、
+// なければ zoxide と同じくプラットフォームごとのデータディレクトリ（Windows では %LOCALAPPDATA%\zoxide）の db.zo です。
+func DBPath() (string, error) {
+	dir, err := dataDir(runtime.GOOS, os.Getenv, os.UserHomeDir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, DBFileName), nil
+}
+
+// dataDir はzoxideのデータベースを置くディレクトリを返します。
+func dataDir(goos string, getenv func(string) string, home func() (string, error)) (string, error) {
+	if dir := getenv(EnvDataDir); dir != "" {
+		return dir, nil
+	}
+
+	var base string
+	switch goos {
+	case "windows":
+		base = getenv("LOCALAPPDATA")
+	case "darwin":
+		h, err := home()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(h, "Library", "Application Support")
+	default:
+		base = getenv("XDG_DATA_HOME")
+		if base == "" {
+			h, err := home()
+			if err != nil {
+				return "", err
+			}
+			base = filepath.Join(h, ".local", "share")
+		}
+	}
+	if base == "" {
+		return "", fmt.Errorf("zoxideのデータディレクトリが見つかりません（%s を設定してください）", EnvDataDir)
+	}
+	return filepath.Join(base, "zoxide"), nil
+}
+
+// ReadDB はzoxideのデータベースファイルを読み込みます。
+// ファイルが存在しない場合は、zoxide と同じく空のデータベースとして扱います。
+func ReadDB(path string) ([]Dir, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Dir{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("zoxideデータベースの読み込みに失敗しました: %w", err)
+	}
+	dirs, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("zoxideデータベースの解析に失敗しました: %s: %w", path, err)
+	}
+	return dirs, nil
+}
+
+// Decode はzoxideのデータベースの内容を解析します。
+// 形式はリトルエンディアンの bincode で、バージョン（u32）に続けてディレクトリの数（u64）と、
+// ディレクトリごとにパス（u64 の長さと UTF-8 のバイト列）、ランク（f64）、最後に移動した日時（u64 の Unix 秒）が並びます。
+// 空のデータは空のデータベースとして扱います。
+func Decode(data []byte) ([]Dir, error) {
+	if len(data) == 0 {
+		return []Dir{}, nil
+	}
+
+	d := decoder{data: data}
+	version := d.uint32()
+	if d.err == nil && version != dbVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+	n := d.uint64()
+	if d.err != nil {
+		return nil, d.err
+	}
+	// 1件あたり最低でも 24 バイトあるため、それを超える件数は壊れたデータとみなす
+	if n > uint64(len(d.data))/24 {
+		return nil, fmt.Errorf("ディレクトリの数が正しくありません: %d", n)
+	}
+
+	dirs := make([]Dir, 0, n)
+	for range n {
+		path := d.string()
+		rank := math.Float64frombits(d.uint64())
+		lastAccessed := d.uint64()
+		if d.err != nil {
+			return nil, d.err
+		}
+		dirs = append(dirs, Dir{Path: path, Rank: rank, LastAccessed: time.Unix(int64(lastAccessed), 0)})
+	}
+	if len(d.data) != 0 {
+		return nil, fmt.Errorf("データの末尾に余分なバイトがあります: %d バイト", len(d.data))
+	}
+	return dirs, nil
+}
+
+// errTruncated はデータが途中で終わっていることを表します。
+var errTruncated = errors.New("データが途中で終わっています")
+
+// decoder は bincode 形式のデータを先頭から読み取ります。
+// 最初に発生したエラーを err に保持し、以降の読み取りはゼロ値を返します。
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.data) < n {
+		d.err = errTruncated
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) string() string {
+	n := d.uint64()
+	if d.err != nil {
+		return ""
+	}
+	if n > uint64(len(d.data)) {
+		d.err = errTruncated
+		return ""
+	}
+	b := d.next(int(n))
+	if !utf8.Valid(b) {
+		d.err = errors.New("パスが UTF-8 ではありません")
+		return ""
+	}
+	return string(b)
+}
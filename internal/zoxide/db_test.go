@@ -0,0 +1,143 @@
+package zoxide
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fixtureNow は testdata のデータベースを作成した時点の Unix 秒（2026-01-01 00:00:00 UTC）です。
+const fixtureNow = 1767225600
+
+// fixtureDirs は testdata/db.zo に記録されているディレクトリです。
+func fixtureDirs() []Dir {
+	now := time.Unix(fixtureNow, 0)
+	return []Dir{
+		{Path: `C:\Users\Test\Projects`, Rank: 10, LastAccessed: now.Add(-30 * time.Minute)},
+		{Path: `C:\code`, Rank: 8, LastAccessed: now.Add(-2 * hour)},
+		{Path: `C:\Users\Test\資料`, Rank: 20, LastAccessed: now.Add(-3 * day)},
+		{Path: `D:\archive`, Rank: 100, LastAccessed: now.Add(-30 * day)},
+	}
+}
+
+func TestReadDB(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		expected []Dir
+		wantErr  error
+	}{
+		{name: "通常のデータベース", file: "db.zo", expected: fixtureDirs()},
+		{name: "空のデータベース", file: "empty.zo", expected: []Dir{}},
+		{name: "存在しないファイル", file: "none.zo", expected: []Dir{}},
+		{name: "対応していないバージョン", file: "unsupported.zo", wantErr: ErrUnsupportedVersion},
+		{name: "途中で終わっている", file: "truncated.zo", wantErr: errTruncated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirs, err := ReadDB(filepath.Join("testdata", tt.file))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("%v が期待されましたが、%v が返りました", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if !reflect.DeepEqual(dirs, tt.expected) {
+				t.Errorf("期待: %+v, 取得: %+v", tt.expected, dirs)
+			}
+		})
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	valid, err := os.ReadFile(filepath.Join("testdata", "db.zo"))
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"バージョンだけ", []byte{3, 0, 0, 0}},
+		{"件数が多すぎる", []byte{3, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}},
+		{"末尾に余分なバイト", append(append([]byte{}, valid...), 0)},
+		{"パスが UTF-8 ではない", []byte{
+			3, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0,
+			1, 0, 0, 0, 0, 0, 0, 0, 0xff,
+			0, 0, 0, 0, 0, 0, 0xf0, 0x3f,
+			0, 0, 0, 0, 0, 0, 0, 0,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.data); err == nil {
+				t.Error("エラーが期待されました")
+			}
+		})
+	}
+}
+
+func TestDirScore(t *testing.T) {
+	now := time.Unix(fixtureNow, 0)
+	tests := []struct {
+		name     string
+		elapsed  time.Duration
+		expected float64
+	}{
+		{"1時間以内", 59 * time.Minute, 40},
+		{"1日以内", hour, 20},
+		{"1週間以内", day, 5},
+		{"1週間より前", week, 2.5},
+		{"未来の日時", -time.Minute, 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Dir{Path: `C:\code`, Rank: 10, LastAccessed: now.Add(-tt.elapsed)}
+			if got := d.Score(now); got != tt.expected {
+				t.Errorf("期待: %v, 取得: %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDataDir(t *testing.T) {
+	home := func() (string, error) { return "/home/test", nil }
+	tests := []struct {
+		name     string
+		goos     string
+		env      map[string]string
+		expected string
+	}{
+		{"_ZO_DATA_DIR を優先", "windows", map[string]string{EnvDataDir: `D:\zoxide`, "LOCALAPPDATA": `C:\Users\Test\AppData\Local`}, `D:\zoxide`},
+		{"Windows", "windows", map[string]string{"LOCALAPPDATA": `C:\Users\Test\AppData\Local`}, filepath.Join(`C:\Users\Test\AppData\Local`, "zoxide")},
+		{"XDG_DATA_HOME", "linux", map[string]string{"XDG_DATA_HOME": "/data"}, filepath.Join("/data", "zoxide")},
+		{"Linux の既定", "linux", nil, filepath.Join("/home/test", ".local", "share", "zoxide")},
+		{"macOS", "darwin", nil, filepath.Join("/home/test", "Library", "Application Support", "zoxide")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dataDir(tt.goos, func(name string) string { return tt.env[name] }, home)
+			if err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("期待: %s, 取得: %s", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := dataDir("windows", func(string) string { return "" }, home); err == nil {
+		t.Error("LOCALAPPDATA が未設定の場合はエラーが期待されました")
+	}
+}
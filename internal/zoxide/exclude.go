@@ -0,0 +1,144 @@
+package zoxide
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/tana9/afxw-tools/internal/winpath"
+)
+
+// EnvExcludeDirs は記録しないディレクトリの glob パターンを指定する環境変数です。
+const EnvExcludeDirs = "_ZO_EXCLUDE_DIRS"
+
+// Exclude は記録しないディレクトリのパターンの集合です。
+type Exclude []*regexp.Regexp
+
+// ExcludeDirs は環境変数 _ZO_EXCLUDE_DIRS から記録しないディレクトリのパターンを返します。
+// zoxide と同じく、未設定の場合はホームディレクトリだけを除外し、空の場合は何も除外しません。
+func ExcludeDirs() (Exclude, error) {
+	v, ok := os.LookupEnv(EnvExcludeDirs)
+	if !ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		re, err := compileGlob(escapeGlob(home))
+		if err != nil {
+			return nil, err
+		}
+		return Exclude{re}, nil
+	}
+	return CompileExclude(v)
+}
+
+// CompileExclude は ; で区切った glob パターンの一覧を解析します。
+// * と ** は区切りを含む任意の文字列、? は任意の1文字、[...] は文字の集合（[!...] は否定）に一致します。
+// Windows と同じく \ と / はどちらも区切りとして扱い、大文字小文字は区別しません。
+func CompileExclude(list string) (Exclude, error) {
+	var e Exclude
+	for _, pattern := range strings.Split(list, ";") {
+		if pattern == "" {
+			continue
+		}
+		re, err := compileGlob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s のパターン %q を解析できません: %w", EnvExcludeDirs, pattern, err)
+		}
+		e = append(e, re)
+	}
+	return e, nil
+}
+
+// Match は path がいずれかのパターンに一致するかを返します。
+func (e Exclude) Match(path string) bool {
+	path = winpath.Clean(path)
+	for _, re := range e {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileGlob は glob パターンをパス全体に一致する正規表現に変換します。
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	pattern = winpath.Clean(pattern)
+
+	var b strings.Builder
+	b.WriteString(`(?is)^`)
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			for i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+			}
+			b.WriteString(`.*`)
+		case '?':
+			b.WriteString(`.`)
+		case '[':
+			end := classEnd(pattern, i)
+			if end < 0 {
+				return nil, fmt.Errorf("[ に対応する ] がありません")
+			}
+			b.WriteString(classRegexp(pattern[i+1 : end]))
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString(`$`)
+	return regexp.Compile(b.String())
+}
+
+// classEnd は start の [ に対応する ] の位置を返します。見つからない場合は -1 を返します。
+// 先頭（! の直後を含む）の ] は文字として扱います。
+func classEnd(pattern string, start int) int {
+	i := start + 1
+	if i < len(pattern) && pattern[i] == '!' {
+		i++
+	}
+	if i < len(pattern) && pattern[i] == ']' {
+		i++
+	}
+	for ; i < len(pattern); i++ {
+		if pattern[i] == ']' {
+			return i
+		}
+	}
+	return -1
+}
+
+// classRegexp は [ と ] の間の文字の集合を正規表現の文字クラスに変換します。
+func classRegexp(class string) string {
+	var b strings.Builder
+	b.WriteString(`[`)
+	if strings.HasPrefix(class, "!") {
+		b.WriteString(`^`)
+		class = class[1:]
+	}
+	for _, r := range class {
+		if r == '-' {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteString(regexp.QuoteMeta(string(r)))
+	}
+	b.WriteString(`]`)
+	return b.String()
+}
+
+// escapeGlob は path の glob の特殊文字を [x] の形にして、path そのものだけに一致するパターンにします。
+func escapeGlob(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		switch r {
+		case '*', '?', '[':
+			b.WriteString("[" + string(r) + "]")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,73 @@
+package zoxide
+
+import "testing"
+
+func TestExclude_Match(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns string
+		path     string
+		expected bool
+	}{
+		{"完全一致", `C:\Users\me`, `C:\Users\me`, true},
+		{"大文字小文字を区別しない", `C:\Users\me`, `c:\users\ME`, true},
+		{"末尾の区切りを無視", `C:\Users\me`, `C:\Users\me\`, true},
+		{"配下は一致しない", `C:\Users\me`, `C:\Users\me\work`, false},
+		{"* は区切りを含めて一致", `C:\Temp\*`, `C:\Temp\a\b`, true},
+		{"* は空文字列にも一致", `C:\Temp*`, `C:\Temp`, true},
+		{"** も同じく一致", `**\node_modules`, `C:\src\app\node_modules`, true},
+		{"? は1文字に一致", `C:\tmp?`, `C:\tmp1`, true},
+		{"? は空文字列に一致しない", `C:\tmp?`, `C:\tmp`, false},
+		{"文字の集合", `C:\[a-c]dir`, `C:\bdir`, true},
+		{"否定の文字の集合", `C:\[!a-c]dir`, `C:\bdir`, false},
+		{"/ も区切りとして扱う", `C:/Temp/*`, `C:\Temp\x`, true},
+		{"正規表現の特殊文字はそのまま", `C:\a.b(1)`, `C:\aXb(1)`, false},
+		{"; で複数指定", `C:\Windows;C:\Temp\*`, `C:\Temp\x`, true},
+		{"空のパターンは無視", `;;`, `C:\Temp`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := CompileExclude(tt.patterns)
+			if err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			if got := e.Match(tt.path); got != tt.expected {
+				t.Errorf("%q に %q が一致するか: 期待: %v, 取得: %v", tt.patterns, tt.path, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCompileExclude_Invalid(t *testing.T) {
+	if _, err := CompileExclude(`C:\[abc`); err == nil {
+		t.Error("エラーが期待されましたが、nilが返りました")
+	}
+}
+
+func TestExcludeDirs(t *testing.T) {
+	t.Setenv("HOME", `C:\Users\[me]`)
+	t.Setenv("USERPROFILE", `C:\Users\[me]`)
+
+	t.Run("未設定の場合はホームディレクトリだけを除外", func(t *testing.T) {
+		unsetEnv(t, EnvExcludeDirs)
+		e, err := ExcludeDirs()
+		if err != nil {
+			t.Fatalf("予期しないエラー: %v", err)
+		}
+		if !e.Match(`C:\Users\[me]`) || e.Match(`C:\Users\m`) || e.Match(`C:\Users\[me]\work`) {
+			t.Errorf("ホームディレクトリだけに一致するべきです")
+		}
+	})
+
+	t.Run("空の場合は何も除外しない", func(t *testing.T) {
+		t.Setenv(EnvExcludeDirs, "")
+		e, err := ExcludeDirs()
+		if err != nil {
+			t.Fatalf("予期しないエラー: %v", err)
+		}
+		if e.Match(`C:\Users\[me]`) {
+			t.Errorf("一致しないべきです")
+		}
+	})
+}
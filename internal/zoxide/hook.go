@@ -0,0 +1,62 @@
+package zoxide
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/config"
+	"github.com/tana9/afxw-tools/internal/winpath"
+)
+
+// Record は dir への移動を dbPath のデータベースに zoxide add と同じく記録します。
+// ランクに 1 を足して日時を now にし、ランクの合計が _ZO_MAXAGE を超えていれば縮めます。
+// _ZO_EXCLUDE_DIRS に一致するディレクトリは記録しません。
+func Record(dbPath string, dir string, now time.Time) error {
+	exclude, err := ExcludeDirs()
+	if err != nil {
+		return err
+	}
+	dir = winpath.Clean(dir)
+	if exclude.Match(dir) {
+		return nil
+	}
+	maxAge, err := MaxAge()
+	if err != nil {
+		return err
+	}
+	return UpdateDB(dbPath, func(dirs []Dir) ([]Dir, error) {
+		return Age(Add(dirs, dir, 1, now), maxAge), nil
+	})
+}
+
+// Hook は移動先を dbPath のzoxideデータベースに記録する afx.JumpHook を返します。
+func Hook(dbPath string) afx.JumpHook {
+	return func(ctx context.Context, dir string) error {
+		if err := Record(dbPath, dir, time.Now()); err != nil {
+			return fmt.Errorf("zoxideへの記録に失敗しました: %w", err)
+		}
+		return nil
+	}
+}
+
+// Wrap は設定ファイルの [zoxide] で record = true の場合に、ディレクトリ移動を
+// zoxideのデータベースに記録する AFX を返します。それ以外の場合は a をそのまま返します。
+func Wrap(a afx.AFX) afx.AFX {
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "警告: %v\n", err)
+		return a
+	}
+	if !cfg.Zoxide.Record {
+		return a
+	}
+	path, err := DBPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "警告: zoxideへの記録を行いません: %v\n", err)
+		return a
+	}
+	return afx.WithJumpHook(a, Hook(path))
+}
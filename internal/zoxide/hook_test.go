@@ -0,0 +1,132 @@
+package zoxide
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tana9/afxw-tools/internal/afx"
+	"github.com/tana9/afxw-tools/internal/afxtest"
+)
+
+func TestRecord(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), DBFileName)
+	now := time.Unix(fixtureNow, 0)
+
+	if err := Record(dbPath, `C:\code\`, now.Add(-hour)); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if err := Record(dbPath, `C:\code`, now); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	dirs, err := ReadDB(dbPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	// 末尾の区切りを取り除いて zoxide と同じ形で記録する
+	expected := []Dir{{Path: `C:\code`, Rank: 2, LastAccessed: now}}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("期待: %+v, 取得: %+v", expected, dirs)
+	}
+}
+
+func TestRecord_Age(t *testing.T) {
+	t.Setenv(EnvMaxAge, "10")
+	dbPath := filepath.Join(t.TempDir(), DBFileName)
+	now := time.Unix(fixtureNow, 0)
+	if err := WriteDB(dbPath, []Dir{
+		{Path: `C:\old`, Rank: 1, LastAccessed: now.Add(-week)},
+		{Path: `C:\code`, Rank: 9, LastAccessed: now.Add(-day)},
+	}); err != nil {
+		t.Fatalf("テストファイル作成に失敗しました: %v", err)
+	}
+
+	if err := Record(dbPath, `C:\code`, now); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	// 合計 11 が上限 10 を超えるため 9/11 倍に縮め、1 未満になった C:\old を取り除く
+	dirs, err := ReadDB(dbPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(dirs) != 1 || dirs[0].Path != `C:\code` {
+		t.Errorf("期待: [C:\\code], 取得: %+v", dirs)
+	}
+}
+
+func TestRecord_Exclude(t *testing.T) {
+	t.Setenv("HOME", `C:\Users\me`)
+	t.Setenv("USERPROFILE", `C:\Users\me`)
+	now := time.Unix(fixtureNow, 0)
+
+	tests := []struct {
+		name     string
+		set      bool // _ZO_EXCLUDE_DIRS を設定する
+		exclude  string
+		dir      string
+		recorded bool
+	}{
+		{"未設定の場合はホームディレクトリを除外", false, "", `C:\Users\me\`, false},
+		{"未設定の場合はホームの配下は記録", false, "", `C:\Users\me\work`, true},
+		{"パターンに一致するディレクトリを除外", true, `C:\Temp\*`, `C:\Temp\build`, false},
+		{"空の場合は何も除外しない", true, "", `C:\Users\me`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.set {
+				t.Setenv(EnvExcludeDirs, tt.exclude)
+			} else {
+				unsetEnv(t, EnvExcludeDirs)
+			}
+			dbPath := filepath.Join(t.TempDir(), DBFileName)
+
+			if err := Record(dbPath, tt.dir, now); err != nil {
+				t.Fatalf("予期しないエラー: %v", err)
+			}
+			dirs, err := ReadDB(dbPath)
+			if err != nil {
+				t.Fatalf("読み込みに失敗しました: %v", err)
+			}
+			if got := len(dirs) == 1; got != tt.recorded {
+				t.Errorf("記録されたか: 期待: %v, 取得: %+v", tt.recorded, dirs)
+			}
+		})
+	}
+}
+
+// unsetEnv はテストの間だけ環境変数 key を削除します。
+func unsetEnv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+func TestHook(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), DBFileName)
+	a := afx.WithJumpHook(&afxtest.MockAFX{}, Hook(dbPath))
+
+	if err := afx.Jump(t.Context(), a, `C:\Left`, false); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+	if err := afx.Jump(t.Context(), a, `D:\Right`, true); err != nil {
+		t.Fatalf("予期しないエラー: %v", err)
+	}
+
+	dirs, err := ReadDB(dbPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	got := make([]string, len(dirs))
+	for i, d := range dirs {
+		got[i] = d.Path
+	}
+	expected := []string{`C:\Left`, `D:\Right`}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, got)
+	}
+}
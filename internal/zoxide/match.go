@@ -0,0 +1,40 @@
+package zoxide
+
+import "strings"
+
+// Match は path が zoxide と同じ規則で keywords に一致するかを返します。
+// 大文字小文字を区別せず、各キーワードが path にこの順で部分文字列として現れ、
+// かつ最後のキーワードが最後のパス要素に含まれる場合に一致します。キーワードがなければ常に一致します。
+func Match(path string, keywords []string) bool {
+	if len(keywords) == 0 {
+		return true
+	}
+
+	path = strings.ToLower(path)
+	last := strings.ToLower(keywords[len(keywords)-1])
+	i := strings.LastIndex(path, last)
+	if i < 0 || strings.ContainsAny(path[i+len(last):], `\/`) {
+		return false
+	}
+	path = path[:i]
+
+	for j := len(keywords) - 2; j >= 0; j-- {
+		i := strings.LastIndex(path, strings.ToLower(keywords[j]))
+		if i < 0 {
+			return false
+		}
+		path = path[:i]
+	}
+	return true
+}
+
+// Filter は keywords に一致するエントリを順序を保ったまま返します。
+func Filter(entries []Entry, keywords []string) []Entry {
+	var matched []Entry
+	for _, e := range entries {
+		if Match(e.Path, keywords) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,47 @@
+package zoxide
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		keywords []string
+		expected bool
+	}{
+		{"キーワードなし", `C:\Users\Test\Projects`, nil, true},
+		{"最後の要素に一致", `C:\Users\Test\Projects`, []string{"proj"}, true},
+		{"大文字小文字を区別しない", `C:\Users\Test\Projects`, []string{"PROJ"}, true},
+		{"最後のキーワードは最後の要素に含まれる必要がある", `C:\Users\Test\Projects`, []string{"test"}, false},
+		{"順序どおり", `C:\Users\Test\Projects\afxw`, []string{"test", "afx"}, true},
+		{"順序が逆", `C:\Users\Test\Projects\afxw`, []string{"afx", "test"}, false},
+		{"同じ要素に複数のキーワード", `C:\work\foobar`, []string{"foo", "bar"}, true},
+		{"同じ部分を2回使わない", `C:\work\foo`, []string{"foo", "foo"}, false},
+		{"区切りをまたぐキーワード", `C:\Users\Test\Projects`, []string{`test\pro`}, true},
+		{"スラッシュ区切り", `/home/test/projects`, []string{"test", "proj"}, true},
+		{"日本語", `C:\Users\Test\資料\2024`, []string{"資料", "24"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Match(tt.path, tt.keywords); got != tt.expected {
+				t.Errorf("Match(%q, %q) 期待: %v, 取得: %v", tt.path, tt.keywords, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	entries := []Entry{
+		{Path: `C:\Users\Test\Projects\afxw`, Score: 40},
+		{Path: `C:\code\afxw-tools`, Score: 20},
+		{Path: `C:\afxw\docs`, Score: 10},
+	}
+	expected := []Entry{entries[0], entries[1]}
+	if got := Filter(entries, []string{"afxw"}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %+v, 取得: %+v", expected, got)
+	}
+}
@@ -0,0 +1,144 @@
+package zoxide
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/tana9/afxw-tools/internal/fileutil"
+	"github.com/tana9/afxw-tools/internal/winpath"
+)
+
+const (
+	// EnvMaxAge はデータベースのランクの合計の上限を指定する環境変数です。
+	EnvMaxAge = "_ZO_MAXAGE"
+	// DefaultMaxAge は _ZO_MAXAGE が未設定の場合のランクの合計の上限です。
+	DefaultMaxAge = 10000
+)
+
+// MaxAge は環境変数 _ZO_MAXAGE からランクの合計の上限を返します。未設定の場合は DefaultMaxAge です。
+func MaxAge() (float64, error) {
+	v := os.Getenv(EnvMaxAge)
+	if v == "" {
+		return DefaultMaxAge, nil
+	}
+	n, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s を整数として解析できません: %q", EnvMaxAge, v)
+	}
+	return float64(n), nil
+}
+
+// index は path を指すディレクトリの位置を返します。見つからない場合は -1 を返します。
+func index(dirs []Dir, path string) int {
+	for i, d := range dirs {
+		if winpath.Equal(d.Path, path) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add は path への移動を記録します。zoxide add と同じく、既にあればランクに by を足して日時を now にし、
+// なければランク by で追加します。ランクは 0 未満になりません。
+func Add(dirs []Dir, path string, by float64, now time.Time) []Dir {
+	now = now.Truncate(time.Second)
+	if i := index(dirs, path); i >= 0 {
+		dirs[i].Rank = max(dirs[i].Rank+by, 0)
+		dirs[i].LastAccessed = now
+		return dirs
+	}
+	return append(dirs, Dir{Path: path, Rank: max(by, 0), LastAccessed: now})
+}
+
+// Merge は他のデータベースから取り込んだディレクトリを dirs にまとめます。
+// zoxide import --merge と同じく、同じパスのランクは足し合わせ、日時は新しい方を残します。
+func Merge(dirs []Dir, imported []Dir) []Dir {
+	for _, d := range imported {
+		if i := index(dirs, d.Path); i >= 0 {
+			dirs[i].Rank += d.Rank
+			if d.LastAccessed.After(dirs[i].LastAccessed) {
+				dirs[i].LastAccessed = d.LastAccessed
+			}
+			continue
+		}
+		d.LastAccessed = d.LastAccessed.Truncate(time.Second)
+		dirs = append(dirs, d)
+	}
+	return dirs
+}
+
+// Remove は path を指すディレクトリを取り除きます。見つからなかった場合は false を返します。
+func Remove(dirs []Dir, path string) ([]Dir, bool) {
+	i := index(dirs, path)
+	if i < 0 {
+		return dirs, false
+	}
+	return append(dirs[:i], dirs[i+1:]...), true
+}
+
+// Age はランクの合計が maxAge を超えている場合に、zoxide と同じくすべてのランクを
+// 合計が maxAge の 9 割になるように縮め、ランクが 1 未満になったディレクトリを取り除きます。
+func Age(dirs []Dir, maxAge float64) []Dir {
+	var total float64
+	for _, d := range dirs {
+		total += d.Rank
+	}
+	if total <= maxAge {
+		return dirs
+	}
+
+	factor := 0.9 * maxAge / total
+	kept := dirs[:0]
+	for _, d := range dirs {
+		d.Rank *= factor
+		if d.Rank >= 1 {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// Encode は dirs をzoxideのデータベースの形式にします。形式は Decode を参照してください。
+func Encode(dirs []Dir) []byte {
+	size := 12
+	for _, d := range dirs {
+		size += 24 + len(d.Path)
+	}
+	b := make([]byte, 0, size)
+	b = binary.LittleEndian.AppendUint32(b, dbVersion)
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(dirs)))
+	for _, d := range dirs {
+		b = binary.LittleEndian.AppendUint64(b, uint64(len(d.Path)))
+		b = append(b, d.Path...)
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(d.Rank))
+		b = binary.LittleEndian.AppendUint64(b, uint64(max(d.LastAccessed.Unix(), 0)))
+	}
+	return b
+}
+
+// WriteDB は dirs をzoxideのデータベースファイルに書き込みます。
+// zoxide が読み込み中でも書き込み途中の内容を読むことはありません。
+func WriteDB(path string, dirs []Dir) error {
+	if err := fileutil.WriteAtomic(path, Encode(dirs)); err != nil {
+		return fmt.Errorf("zoxideデータベースの書き込みに失敗しました: %w", err)
+	}
+	return nil
+}
+
+// UpdateDB はzoxideのデータベースを読み込み、fn で更新してから書き込みます。
+// fn がエラーを返した場合は書き込みません。
+func UpdateDB(path string, fn func(dirs []Dir) ([]Dir, error)) error {
+	dirs, err := ReadDB(path)
+	if err != nil {
+		return err
+	}
+	dirs, err = fn(dirs)
+	if err != nil {
+		return err
+	}
+	return WriteDB(path, dirs)
+}
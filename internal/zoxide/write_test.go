@@ -0,0 +1,172 @@
+package zoxide
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncode_Fixture(t *testing.T) {
+	expected, err := os.ReadFile(filepath.Join("testdata", "db.zo"))
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if got := Encode(fixtureDirs()); !bytes.Equal(got, expected) {
+		t.Errorf("zoxide の形式と一致しません:\n期待: %x\n取得: %x", expected, got)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	now := time.Unix(fixtureNow, 0)
+	dirs := []Dir{{Path: `C:\code`, Rank: 3, LastAccessed: now.Add(-day)}}
+
+	dirs = Add(dirs, `c:\code\`, 1, now.Add(500*time.Millisecond))
+	dirs = Add(dirs, `C:\new`, 1, now)
+	dirs = Add(dirs, `C:\negative`, -5, now)
+
+	expected := []Dir{
+		{Path: `C:\code`, Rank: 4, LastAccessed: now},
+		{Path: `C:\new`, Rank: 1, LastAccessed: now},
+		{Path: `C:\negative`, Rank: 0, LastAccessed: now},
+	}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("期待: %+v, 取得: %+v", expected, dirs)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	now := time.Unix(fixtureNow, 0)
+	dirs := []Dir{
+		{Path: `C:\code`, Rank: 3, LastAccessed: now.Add(-day)},
+		{Path: `C:\docs`, Rank: 2, LastAccessed: now},
+	}
+	imported := []Dir{
+		{Path: `C:\code`, Rank: 5, LastAccessed: now.Add(-hour)},
+		{Path: `C:\docs`, Rank: 1, LastAccessed: now.Add(-week)},
+		{Path: `C:\new`, Rank: 7, LastAccessed: now.Add(-2 * day)},
+	}
+
+	// ランクは足し合わせ、日時は新しい方を残す
+	expected := []Dir{
+		{Path: `C:\code`, Rank: 8, LastAccessed: now.Add(-hour)},
+		{Path: `C:\docs`, Rank: 3, LastAccessed: now},
+		{Path: `C:\new`, Rank: 7, LastAccessed: now.Add(-2 * day)},
+	}
+	if got := Merge(dirs, imported); !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %+v, 取得: %+v", expected, got)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	dirs := fixtureDirs()
+
+	dirs, ok := Remove(dirs, `c:\CODE`)
+	if !ok {
+		t.Fatal("削除されませんでした")
+	}
+	if _, ok := Remove(dirs, `C:\none`); ok {
+		t.Error("存在しないパスは削除できないべきです")
+	}
+
+	expected := []string{`C:\Users\Test\Projects`, `C:\Users\Test\資料`, `D:\archive`}
+	got := make([]string, len(dirs))
+	for i, d := range dirs {
+		got[i] = d.Path
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %q, 取得: %q", expected, got)
+	}
+}
+
+func TestAge(t *testing.T) {
+	tests := []struct {
+		name     string
+		ranks    []float64
+		maxAge   float64
+		expected []float64
+	}{
+		{"上限以下は変更しない", []float64{50, 30, 20}, 100, []float64{50, 30, 20}},
+		// 合計 200 を上限 100 の 9 割に縮める（0.45 倍）と、2 は 1 未満になって取り除かれる
+		{"上限を超えると縮める", []float64{150, 48, 2}, 100, []float64{67.5, 21.6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirs := make([]Dir, len(tt.ranks))
+			for i, r := range tt.ranks {
+				dirs[i] = Dir{Path: filepath.Join("dir", string(rune('a'+i))), Rank: r}
+			}
+			dirs = Age(dirs, tt.maxAge)
+
+			if len(dirs) != len(tt.expected) {
+				t.Fatalf("期待: %v, 取得: %+v", tt.expected, dirs)
+			}
+			for i, d := range dirs {
+				if diff := d.Rank - tt.expected[i]; diff > 1e-9 || diff < -1e-9 {
+					t.Errorf("%d: 期待: %v, 取得: %v", i, tt.expected[i], d.Rank)
+				}
+			}
+		})
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected float64
+		wantErr  bool
+	}{
+		{"未設定", "", DefaultMaxAge, false},
+		{"指定", "5000", 5000, false},
+		{"整数以外", "abc", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvMaxAge, tt.value)
+			got, err := MaxAge()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("エラー 期待: %v, 取得: %v", tt.wantErr, err)
+			}
+			if got != tt.expected {
+				t.Errorf("期待: %v, 取得: %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestUpdateDB(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "zoxide", DBFileName)
+	now := time.Unix(fixtureNow, 0)
+
+	// 存在しないデータベースは空として作成する
+	for range 2 {
+		if err := UpdateDB(dbPath, func(dirs []Dir) ([]Dir, error) {
+			return Add(dirs, `C:\code`, 1, now), nil
+		}); err != nil {
+			t.Fatalf("予期しないエラー: %v", err)
+		}
+	}
+
+	dirs, err := ReadDB(dbPath)
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	expected := []Dir{{Path: `C:\code`, Rank: 2, LastAccessed: now}}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("期待: %+v, 取得: %+v", expected, dirs)
+	}
+
+	// 一時ファイルを残さない
+	entries, err := os.ReadDir(filepath.Dir(dbPath))
+	if err != nil {
+		t.Fatalf("読み込みに失敗しました: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("一時ファイルが残っています: %v", entries)
+	}
+}
@@ -0,0 +1,86 @@
+// Package zoxide は zoxide のデータベース（db.zo）を zoxide コマンドを使わずに読み書きします。
+//
+// afxw-zox のほか、あふwでの移動を zoxide に記録するために各ツールから使います。
+package zoxide
+
+import (
+	"cmp"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxConcurrentStats は同時に存在を確認するディレクトリの数です。
+const maxConcurrentStats = 16
+
+// Entry はzoxideのディレクトリエントリを表します。
+type Entry struct {
+	Path  string  // ディレクトリパス
+	Score float64 // frecencyスコア
+}
+
+// Query はzoxideのデータベースを直接読み込んでディレクトリリストを取得します。
+// zoxide コマンドは使いません。存在しないディレクトリを除き、スコアの高い順（降順）でソートされたエントリを返します。
+func Query() ([]Entry, error) {
+	path, err := DBPath()
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := ReadDB(path)
+	if err != nil {
+		return nil, err
+	}
+	return Rank(dirs, time.Now(), dirExists), nil
+}
+
+// Rank は now の時点でのスコアを計算し、スコアの高い順に並べたエントリを返します。
+// スコアが同じ場合は最後に移動した日時が新しいものを先にします。
+// exists が false を返すディレクトリは除きます。確認は並行して行います。
+func Rank(dirs []Dir, now time.Time, exists func(path string) bool) []Entry {
+	ok := make([]bool, len(dirs))
+	sem := make(chan struct{}, maxConcurrentStats)
+	var wg sync.WaitGroup
+	for i, d := range dirs {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			ok[i] = exists(d.Path)
+		})
+	}
+	wg.Wait()
+
+	kept := make([]Dir, 0, len(dirs))
+	for i, d := range dirs {
+		if ok[i] {
+			kept = append(kept, d)
+		}
+	}
+	slices.SortStableFunc(kept, func(a, b Dir) int {
+		if c := cmp.Compare(b.Score(now), a.Score(now)); c != 0 {
+			return c
+		}
+		return b.LastAccessed.Compare(a.LastAccessed)
+	})
+
+	entries := make([]Entry, len(kept))
+	for i, d := range kept {
+		entries[i] = Entry{Path: d.Path, Score: d.Score(now)}
+	}
+	return entries
+}
+
+// dirExists は path のディレクトリが存在するかを返します。
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// Paths はエントリからパスのみを抽出して返します。
+func Paths(entries []Entry) []string {
+	paths := make([]string, len(entries))
+	for i, entry := range entries {
+		paths[i] = entry.Path
+	}
+	return paths
+}
@@ -0,0 +1,56 @@
+package zoxide
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRank(t *testing.T) {
+	now := time.Unix(fixtureNow, 0)
+	dirs := []Dir{
+		{Path: `C:\old`, Rank: 100, LastAccessed: now.Add(-30 * day)},      // 25
+		{Path: `C:\gone`, Rank: 50, LastAccessed: now},                     // 存在しない
+		{Path: `C:\recent`, Rank: 10, LastAccessed: now.Add(-time.Minute)}, // 40
+		{Path: `C:\tie-old`, Rank: 5, LastAccessed: now.Add(-2 * hour)},    // 10
+		{Path: `C:\tie-new`, Rank: 20, LastAccessed: now.Add(-2 * day)},    // 10
+	}
+	exists := func(path string) bool { return path != `C:\gone` }
+
+	expected := []Entry{
+		{Path: `C:\recent`, Score: 40},
+		{Path: `C:\old`, Score: 25},
+		// スコアが同じ場合は最後に移動した日時が新しいものを先にする
+		{Path: `C:\tie-old`, Score: 10},
+		{Path: `C:\tie-new`, Score: 10},
+	}
+	if got := Rank(dirs, now, exists); !reflect.DeepEqual(got, expected) {
+		t.Errorf("期待: %+v, 取得: %+v", expected, got)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	entries := []Entry{
+		{Path: "C:\\Users\\Test", Score: 10.0},
+		{Path: "C:\\Program Files\\MyApp", Score: 15.0},
+		{Path: "C:\\My Folder\\Sub Folder", Score: 20.0},
+	}
+
+	expected := []string{
+		"C:\\Users\\Test",
+		"C:\\Program Files\\MyApp",
+		"C:\\My Folder\\Sub Folder",
+	}
+
+	result := Paths(entries)
+
+	if len(result) != len(expected) {
+		t.Fatalf("パス数が一致しません: got %d, want %d", len(result), len(expected))
+	}
+
+	for i, path := range result {
+		if path != expected[i] {
+			t.Errorf("パスが一致しません [%d]: got %q, want %q", i, path, expected[i])
+		}
+	}
+}